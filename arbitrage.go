@@ -0,0 +1,181 @@
+package gonomics
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Arbitrage Scanner.
+
+// ArbitrageScanner finds cross-exchange arbitrage opportunities in the exchange-markets ticker,
+// by comparing the prices of the same base / quote pair across exchanges.
+type ArbitrageScanner struct {
+	c *Connecter
+
+	// Request is the exchange-markets ticker request used by Poll and Stream.
+	Request ExchangeMarketsTickerRequest
+
+	// Fees per exchange id as a fraction, like 0.001 for 0.1% fee. DefaultFee is used for exchanges not in Fees.
+	Fees       map[string]float64
+	DefaultFee float64
+
+	// MaxAge filters out markets with LastUpdated older than MaxAge. Default 0 means no filtering.
+	MaxAge time.Duration
+
+	// MinVolumeUSD filters out markets with VolumeUSD lower than MinVolumeUSD.
+	MinVolumeUSD float64
+
+	// MinSpreadPct is the minimum net spread, as a fraction, for the opportunity to be reported.
+	MinSpreadPct float64
+}
+
+// ArbitrageOpportunity represents buying the pair on one exchange and selling it on another.
+// Spread pct fields are fractions (0.05 is 5%).
+type ArbitrageOpportunity struct {
+	Base  string
+	Quote string
+
+	Buy  ExchangeMarketsTickerResponse
+	Sell ExchangeMarketsTickerResponse
+
+	// Buy and sell prices in quote currency, including the exchange fees.
+	BuyPrice  float64
+	SellPrice float64
+
+	// Spread between Buy and Sell PriceQuote, without and with fees.
+	GrossSpreadPct float64
+	NetSpreadPct   float64
+}
+
+// ArbitrageScan represents the result of one poll of the ArbitrageScanner.
+type ArbitrageScan struct {
+	Timestamp     time.Time
+	Opportunities []ArbitrageOpportunity
+	Err           error
+}
+
+// NewArbitrageScanner creates a brand new ArbitrageScanner polling the exchange-markets ticker with emtReq.
+// Modify ArbitrageScanner fees and filters to specific needs once this function returns.
+func (c *Connecter) NewArbitrageScanner(emtReq ExchangeMarketsTickerRequest) *ArbitrageScanner {
+	return &ArbitrageScanner{
+		c:       c,
+		Request: emtReq,
+		Fees:    make(map[string]float64),
+	}
+}
+
+// Poll fetches the exchange-markets ticker from the server and returns the arbitrage opportunities found.
+func (as *ArbitrageScanner) Poll() ([]ArbitrageOpportunity, error) {
+	return as.poll(as.c)
+}
+
+// Stream polls the exchange-markets ticker every interval until the ctx is done,
+// and sends the result of each poll on the returned channel, which is closed once the ctx is done.
+// The ctx also cancels the poll in flight.
+func (as *ArbitrageScanner) Stream(ctx context.Context, interval time.Duration) <-chan ArbitrageScan {
+	scans := make(chan ArbitrageScan)
+	c := as.c.WithContext(ctx)
+	go func() {
+		defer close(scans)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			opportunities, err := as.poll(c)
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case scans <- ArbitrageScan{Timestamp: time.Now(), Opportunities: opportunities, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return scans
+}
+
+// Scan groups the markets by normalized base / quote pair, finds the best exchange to buy and to sell
+// each pair after fees, and returns the opportunities sorted by NetSpreadPct, highest first.
+// Stale, low volume and PriceExclude markets are skipped, now is used to check staleness.
+func (as *ArbitrageScanner) Scan(emtResp []ExchangeMarketsTickerResponse, now time.Time) []ArbitrageOpportunity {
+	type pair struct{ base, quote string }
+	groups := make(map[pair][]ExchangeMarketsTickerResponse)
+	var order []pair
+	for _, emt := range emtResp {
+		if emt.PriceExclude || emt.PriceQuote <= 0 || emt.VolumeUSD < as.MinVolumeUSD {
+			continue
+		}
+		if as.MaxAge > 0 && now.Sub(emt.LastUpdated) > as.MaxAge {
+			continue
+		}
+		p := pair{strings.ToUpper(emt.Base), strings.ToUpper(emt.Quote)}
+		if _, ok := groups[p]; !ok {
+			order = append(order, p)
+		}
+		groups[p] = append(groups[p], emt)
+	}
+
+	var opportunities []ArbitrageOpportunity
+	for _, p := range order {
+		markets := groups[p]
+		if len(markets) < 2 {
+			continue
+		}
+		buy, sell := -1, -1
+		var buyPrice, sellPrice float64
+		for i, emt := range markets {
+			fee := as.fee(emt.Exchange)
+			if price := emt.PriceQuote * (1 + fee); buy == -1 || price < buyPrice {
+				buy, buyPrice = i, price
+			}
+			if price := emt.PriceQuote * (1 - fee); sell == -1 || price > sellPrice {
+				sell, sellPrice = i, price
+			}
+		}
+		if markets[buy].Exchange == markets[sell].Exchange {
+			continue
+		}
+		opportunity := ArbitrageOpportunity{
+			Base:           p.base,
+			Quote:          p.quote,
+			Buy:            markets[buy],
+			Sell:           markets[sell],
+			BuyPrice:       buyPrice,
+			SellPrice:      sellPrice,
+			GrossSpreadPct: (markets[sell].PriceQuote - markets[buy].PriceQuote) / markets[buy].PriceQuote,
+			NetSpreadPct:   (sellPrice - buyPrice) / buyPrice,
+		}
+		if opportunity.NetSpreadPct <= as.MinSpreadPct {
+			continue
+		}
+		opportunities = append(opportunities, opportunity)
+	}
+	sort.SliceStable(opportunities, func(i, j int) bool {
+		return opportunities[i].NetSpreadPct > opportunities[j].NetSpreadPct
+	})
+	return opportunities
+}
+
+// fee returns the fee of the exchange, DefaultFee if it is not configured.
+func (as *ArbitrageScanner) fee(exchange string) float64 {
+	if fee, ok := as.Fees[exchange]; ok {
+		return fee
+	}
+	return as.DefaultFee
+}
+
+// poll fetches the exchange-markets ticker with the Connecter and returns the arbitrage opportunities found.
+func (as *ArbitrageScanner) poll(c *Connecter) ([]ArbitrageOpportunity, error) {
+	emtResp, err := c.GetExchangeMarketsTicker(as.Request)
+	if err != nil {
+		return nil, err
+	}
+	return as.Scan(emtResp, time.Now()), nil
+}
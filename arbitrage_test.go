@@ -0,0 +1,67 @@
+package gonomics
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestArbitrageScan tests arbitrage opportunities scanning function.
+func TestArbitrageScan(t *testing.T) {
+	now := time.Now()
+	as := New(demoAPIKey).NewArbitrageScanner(ExchangeMarketsTickerRequest{})
+	as.DefaultFee = 0.001
+	as.Fees["kraken"] = 0.002
+	as.MaxAge = time.Minute
+	as.MinVolumeUSD = 1000
+
+	emtResp := []ExchangeMarketsTickerResponse{
+		{Exchange: "binance", Base: "BTC", Quote: "USDT", PriceQuote: 100, VolumeUSD: 5000, LastUpdated: now},
+		{Exchange: "kraken", Base: "btc", Quote: "usdt", PriceQuote: 102, VolumeUSD: 5000, LastUpdated: now},
+		{Exchange: "stale", Base: "BTC", Quote: "USDT", PriceQuote: 120, VolumeUSD: 5000, LastUpdated: now.Add(-time.Hour)},
+		{Exchange: "tiny", Base: "BTC", Quote: "USDT", PriceQuote: 90, VolumeUSD: 10, LastUpdated: now},
+		{Exchange: "excluded", Base: "BTC", Quote: "USDT", PriceQuote: 80, VolumeUSD: 5000, LastUpdated: now, PriceExclude: true},
+		{Exchange: "binance", Base: "ETH", Quote: "USDT", PriceQuote: 10, VolumeUSD: 5000, LastUpdated: now},
+	}
+	opportunities := as.Scan(emtResp, now)
+	if len(opportunities) != 1 {
+		t.Fatalf("Something is wrong here, expected 1 opportunity, got %v.", len(opportunities))
+	}
+	o := opportunities[0]
+	if o.Buy.Exchange != "binance" || o.Sell.Exchange != "kraken" || o.Base != "BTC" {
+		t.Errorf("Something is wrong here, unexpected opportunity %v.", o)
+	}
+	if math.Abs(o.GrossSpreadPct-0.02) > 1e-9 {
+		t.Errorf("Something is wrong here, expected gross spread 0.02, got %v.", o.GrossSpreadPct)
+	}
+	if math.Abs(o.NetSpreadPct-(102*0.998-100.1)/100.1) > 1e-9 {
+		t.Errorf("Something is wrong here, unexpected net spread %v.", o.NetSpreadPct)
+	}
+
+	as.MinSpreadPct = 0.05
+	if len(as.Scan(emtResp, now)) != 0 {
+		t.Error("Something is wrong here, opportunity below minimum spread is reported.")
+	}
+}
+
+// TestArbitrageStream tests that cancelling the stream cancels the poll in flight.
+func TestArbitrageStream(t *testing.T) {
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	scans := c.NewArbitrageScanner(ExchangeMarketsTickerRequest{}).Stream(ctx, time.Hour)
+	cancel()
+	select {
+	case _, ok := <-scans:
+		if ok {
+			t.Error("Something is wrong here, expected no scan after cancel.")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Something is wrong here, expected stream closed after cancel.")
+	}
+}
@@ -0,0 +1,365 @@
+package gonomics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backfill Jobs.
+
+const (
+	// backfillCheckpointFile is the checkpoint file name in the backfill job output directory.
+	backfillCheckpointFile = "checkpoint.json"
+	// backfillTradesLimit is the page size of the trades requests.
+	backfillTradesLimit = 100
+	// backfillCheckpointInterval is the minimum time between two checkpoint writes during a run.
+	backfillCheckpointInterval = time.Second
+)
+
+// BackfillMarket represents one market of a backfill job.
+// Candles use Currency, Markets Candles use Base and Quote, Exchange Candles, Trades and Orders Snapshot use Exchange and Market.
+type BackfillMarket struct {
+	Currency string `json:"currency,omitempty"`
+	Base     string `json:"base,omitempty"`
+	Quote    string `json:"quote,omitempty"`
+	Exchange string `json:"exchange,omitempty"`
+	Market   string `json:"market,omitempty"`
+}
+
+// BackfillSpec represents a declarative backfill job.
+// Supported endpoints are EndpointCandles, EndpointExchangeCandles, EndpointMarketsCandles, EndpointTrades and EndpointOrdersSnapshot.
+// The time range is split into work units of UnitDuration, default 24 hours, per endpoint and market.
+// For Orders Snapshot, one snapshot is fetched at the start of every work unit.
+type BackfillSpec struct {
+	Endpoints []string         `json:"endpoints"`
+	Markets   []BackfillMarket `json:"markets"`
+	Interval  string           `json:"interval,omitempty"`
+	Start     time.Time        `json:"start"`
+	End       time.Time        `json:"end"`
+
+	UnitDuration time.Duration `json:"unit_duration,omitempty"`
+
+	// Output directory, every work unit is written to its own json file in it, along with the checkpoint file.
+	Dir string `json:"dir"`
+
+	// Number of work units executed in parallel, default 1.
+	// The requests of the units also wait for the rate limit of the Connecter, if set, see SetRateLimit.
+	Workers int `json:"workers,omitempty"`
+}
+
+// BackfillUnit represents one work unit of a backfill job.
+type BackfillUnit struct {
+	ID       string
+	Endpoint string
+	Market   BackfillMarket
+	Start    time.Time
+	End      time.Time
+}
+
+// BackfillProgress represents the progress of a backfill job.
+type BackfillProgress struct {
+	Total  int
+	Done   int
+	Failed map[string]error
+}
+
+// BackfillJob runs a BackfillSpec, checkpointing the done work units, so that a new job with the same spec
+// resumes where the previous one left off. Workers may differ between the runs.
+// The checkpoint is written at most every second and at the end of the run, so units done just before a crash may run again.
+type BackfillJob struct {
+	Spec BackfillSpec
+
+	// OnProgress, if set, is called after every work unit.
+	OnProgress func(BackfillUnit, BackfillProgress)
+
+	c     *Connecter
+	units []BackfillUnit
+}
+
+// backfillCheckpoint is the checkpoint file content.
+type backfillCheckpoint struct {
+	Spec BackfillSpec    `json:"spec"`
+	Done map[string]bool `json:"done"`
+}
+
+// LoadBackfillSpec reads a json BackfillSpec file.
+func LoadBackfillSpec(fileNameWithPath string) (BackfillSpec, error) {
+	var spec BackfillSpec
+	data, err := ioutil.ReadFile(fileNameWithPath)
+	if err != nil {
+		return spec, err
+	}
+	err = json.Unmarshal(data, &spec)
+	return spec, err
+}
+
+// NewBackfillJob validates the spec and splits it into work units.
+func (c *Connecter) NewBackfillJob(spec BackfillSpec) (*BackfillJob, error) {
+	if spec.Dir == "" {
+		return nil, errors.New("output directory is required")
+	}
+	if spec.Start.IsZero() || spec.End.IsZero() || !spec.Start.Before(spec.End) {
+		return nil, errors.New("valid start and end are required")
+	}
+	if spec.UnitDuration <= 0 {
+		spec.UnitDuration = 24 * time.Hour
+	}
+	if spec.Workers <= 0 {
+		spec.Workers = 1
+	}
+
+	j := &BackfillJob{Spec: spec, c: c}
+	for _, e := range spec.Endpoints {
+		switch e {
+		case EndpointCandles, EndpointExchangeCandles, EndpointMarketsCandles:
+			if _, err := intervalDuration(spec.Interval); err != nil {
+				return nil, err
+			}
+		case EndpointTrades, EndpointOrdersSnapshot:
+		default:
+			return nil, fmt.Errorf("endpoint %v is not supported for backfill", e)
+		}
+		for _, m := range spec.Markets {
+			key := backfillMarketKey(e, m)
+			if key == "" {
+				return nil, fmt.Errorf("market %+v is incomplete for endpoint %v", m, e)
+			}
+			for start := spec.Start; start.Before(spec.End); start = start.Add(spec.UnitDuration) {
+				end := start.Add(spec.UnitDuration)
+				if end.After(spec.End) {
+					end = spec.End
+				}
+				id := strings.Join([]string{e, key, start.UTC().Format(time.RFC3339)}, "/")
+				j.units = append(j.units, BackfillUnit{ID: id, Endpoint: e, Market: m, Start: start, End: end})
+			}
+		}
+	}
+	if len(j.units) == 0 {
+		return nil, errors.New("endpoints and markets are required")
+	}
+	return j, nil
+}
+
+// Units returns the work units of the job.
+func (j *BackfillJob) Units() []BackfillUnit {
+	return j.units
+}
+
+// Run executes the work units not done yet by a previous run, with Spec.Workers workers, until all the units
+// are executed or the ctx is cancelled. Failed units are reported in the progress, and retried by the next run.
+func (j *BackfillJob) Run(ctx context.Context) (BackfillProgress, error) {
+	progress := BackfillProgress{Total: len(j.units), Failed: make(map[string]error)}
+	if err := os.MkdirAll(j.Spec.Dir, 0755); err != nil {
+		return progress, err
+	}
+	cp, err := j.loadCheckpoint()
+	if err != nil {
+		return progress, err
+	}
+	progress.Done = len(cp.Done)
+
+	var pending []BackfillUnit
+	for _, u := range j.units {
+		if !cp.Done[u.ID] {
+			pending = append(pending, u)
+		}
+	}
+
+	c := j.c.WithContext(ctx)
+	units := make(chan BackfillUnit)
+	var mu sync.Mutex
+	var saveErr error
+	saved, dirty := time.Now(), false
+	var wg sync.WaitGroup
+	for i := 0; i < j.Spec.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range units {
+				err := j.runUnit(c, u)
+				mu.Lock()
+				if err != nil {
+					progress.Failed[u.ID] = err
+				} else {
+					cp.Done[u.ID] = true
+					progress.Done++
+					dirty = true
+					if time.Since(saved) >= backfillCheckpointInterval {
+						if err := j.saveCheckpoint(cp); err != nil && saveErr == nil {
+							saveErr = err
+						}
+						saved, dirty = time.Now(), false
+					}
+				}
+				if j.OnProgress != nil {
+					j.OnProgress(u, progress)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for _, u := range pending {
+		select {
+		case units <- u:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(units)
+	wg.Wait()
+
+	if dirty {
+		if err := j.saveCheckpoint(cp); err != nil && saveErr == nil {
+			saveErr = err
+		}
+	}
+	if saveErr != nil {
+		return progress, saveErr
+	}
+	if err := ctx.Err(); err != nil {
+		return progress, err
+	}
+	if len(progress.Failed) > 0 {
+		return progress, fmt.Errorf("%v of %v backfill units failed", len(progress.Failed), progress.Total)
+	}
+	return progress, nil
+}
+
+// Helper methods
+
+// backfillMarketKey returns the market part of the work unit id, or empty string if the market is incomplete for the endpoint.
+func backfillMarketKey(e string, m BackfillMarket) string {
+	switch e {
+	case EndpointCandles:
+		if m.Currency != "" {
+			return m.Currency
+		}
+	case EndpointMarketsCandles:
+		if m.Base != "" && m.Quote != "" {
+			return m.Base + "-" + m.Quote
+		}
+	default:
+		if m.Exchange != "" && m.Market != "" {
+			return m.Exchange + "-" + m.Market
+		}
+	}
+	return ""
+}
+
+// runUnit fetches the work unit data and atomically writes it to the unit output file.
+func (j *BackfillJob) runUnit(c *Connecter, u BackfillUnit) error {
+	var data interface{}
+	var err error
+	switch u.Endpoint {
+	case EndpointCandles:
+		data, err = c.GetCandles(CandlesRequest{Interval: j.Spec.Interval, Currency: u.Market.Currency, Start: u.Start, End: u.End})
+	case EndpointExchangeCandles:
+		data, err = c.GetExchangeCandles(ExchangeCandlesRequest{Interval: j.Spec.Interval, Exchange: u.Market.Exchange, Market: u.Market.Market, Start: u.Start, End: u.End})
+	case EndpointMarketsCandles:
+		data, err = c.GetMarketsCandles(MarketsCandlesRequest{Interval: j.Spec.Interval, Base: u.Market.Base, Quote: u.Market.Quote, Start: u.Start, End: u.End})
+	case EndpointTrades:
+		data, err = backfillTrades(c, u)
+	case EndpointOrdersSnapshot:
+		data, err = c.GetOrdersSnapshot(OrdersSnapshotRequest{Exchange: u.Market.Exchange, Market: u.Market.Market, At: u.Start})
+	}
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(j.Spec.Dir, backfillFileName(u)), b)
+}
+
+// backfillTrades pages through the trades of the work unit time range.
+func backfillTrades(c *Connecter, u BackfillUnit) ([]TradesResponse, error) {
+	var trades []TradesResponse
+	seen := make(map[string]bool)
+	from := u.Start
+	for {
+		tResp, err := c.GetTrades(TradesRequest{Exchange: u.Market.Exchange, Market: u.Market.Market, Limit: backfillTradesLimit, Order: "asc", From: from})
+		if err != nil {
+			return nil, err
+		}
+		last := from
+		for _, t := range tResp {
+			if t.Timestamp.After(last) {
+				last = t.Timestamp
+			}
+			if t.Timestamp.Before(u.Start) || !t.Timestamp.Before(u.End) || seen[t.ID] {
+				continue
+			}
+			seen[t.ID] = true
+			trades = append(trades, t)
+		}
+		if len(tResp) < backfillTradesLimit || !last.After(from) || !last.Before(u.End) {
+			return trades, nil
+		}
+		from = last
+	}
+}
+
+// backfillFileName returns the output file name of the work unit.
+func backfillFileName(u BackfillUnit) string {
+	return strings.NewReplacer("/", "_", ":", "", "\\", "_").Replace(u.ID) + ".json"
+}
+
+// loadCheckpoint reads the checkpoint of the job, if any, after checking it belongs to a spec with the same work units.
+func (j *BackfillJob) loadCheckpoint() (*backfillCheckpoint, error) {
+	cp := &backfillCheckpoint{Spec: j.Spec, Done: make(map[string]bool)}
+	data, err := ioutil.ReadFile(filepath.Join(j.Spec.Dir, backfillCheckpointFile))
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved backfillCheckpoint
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(backfillUnitsKey(j.Spec), backfillUnitsKey(saved.Spec)) {
+		return nil, errors.New("checkpoint belongs to a different backfill spec")
+	}
+	for id := range saved.Done {
+		cp.Done[id] = true
+	}
+	return cp, nil
+}
+
+// backfillUnitsKey returns the spec fields defining the work units, without the execution settings like Workers.
+func backfillUnitsKey(spec BackfillSpec) []byte {
+	spec.Workers = 0
+	key, _ := json.Marshal(spec)
+	return key
+}
+
+// saveCheckpoint atomically writes the checkpoint of the job.
+func (j *BackfillJob) saveCheckpoint(cp *backfillCheckpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(j.Spec.Dir, backfillCheckpointFile), data)
+}
+
+// writeFileAtomic writes the data to a temporary file and renames it to name, so that name is never partially written.
+func writeFileAtomic(name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
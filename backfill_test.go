@@ -0,0 +1,129 @@
+package gonomics
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestBackfillJob tests splitting, checkpointing and resuming of a backfill job.
+func TestBackfillJob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonomics_backfill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	failing := "ETH"
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		currency := req.URL.Query().Get("currency")
+		calls[currency]++
+		if currency == failing {
+			return newTestResponse(req, http.StatusInternalServerError, ""), nil
+		}
+		return newTestResponse(req, http.StatusOK, `[{"timestamp":"`+req.URL.Query().Get("start")+`","close":"1"}]`), nil
+	})
+
+	startTime, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+	spec := BackfillSpec{
+		Endpoints: []string{EndpointCandles},
+		Markets:   []BackfillMarket{{Currency: "BTC"}, {Currency: "ETH"}},
+		Interval:  "1h",
+		Start:     startTime,
+		End:       startTime.Add(3 * 24 * time.Hour),
+		Dir:       dir,
+		Workers:   2,
+	}
+	j, err := c.NewBackfillJob(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(j.Units()) != 6 {
+		t.Fatalf("Something is wrong here, expected 6 work units, got %v.", len(j.Units()))
+	}
+
+	progress, err := j.Run(context.Background())
+	if err == nil || progress.Done != 3 || len(progress.Failed) != 3 {
+		t.Errorf("Something is wrong here, unexpected progress %+v, error %v.", progress, err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "candles_BTC_*.json"))
+	if len(files) != 3 {
+		t.Errorf("Something is wrong here, expected 3 output files, got %v.", files)
+	}
+
+	// Resume runs only the failed units, with more workers.
+	mu.Lock()
+	failing = ""
+	mu.Unlock()
+	spec.Workers = 4
+	j, err = c.NewBackfillJob(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress, err = j.Run(context.Background())
+	if err != nil || progress.Done != 6 {
+		t.Errorf("Something is wrong here, unexpected progress %+v, error %v.", progress, err)
+	}
+	if calls["BTC"] != 3 || calls["ETH"] != 6 {
+		t.Errorf("Something is wrong here, unexpected server calls %v.", calls)
+	}
+
+	// Checkpoint of a different spec is refused.
+	spec.Interval = "1d"
+	j, err = c.NewBackfillJob(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Run(context.Background()); err == nil {
+		t.Error("Something is wrong here, expected error for a different spec checkpoint.")
+	}
+
+	if _, err := c.NewBackfillJob(BackfillSpec{Endpoints: []string{EndpointGlobalTicker}, Markets: spec.Markets, Start: spec.Start, End: spec.End, Dir: dir}); err == nil {
+		t.Error("Something is wrong here, expected error for unsupported endpoint.")
+	}
+}
+
+// TestBackfillJobRateLimit tests that the work units wait for the rate limit of the Connecter.
+func TestBackfillJobRateLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonomics_backfill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(req, http.StatusOK, `[]`), nil
+	})
+	c.SetRateLimit(50, 1)
+	startTime, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+	j, err := c.NewBackfillJob(BackfillSpec{
+		Endpoints: []string{EndpointCandles},
+		Markets:   []BackfillMarket{{Currency: "BTC"}},
+		Interval:  "1d",
+		Start:     startTime,
+		End:       startTime.Add(4 * 24 * time.Hour),
+		Dir:       dir,
+		Workers:   4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := j.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Errorf("Something is wrong here, expected 4 units spaced by 20ms, got %v.", d)
+	}
+}
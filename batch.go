@@ -0,0 +1,122 @@
+package gonomics
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Batch Fetching.
+
+// BatchOptions represents batch fetching options.
+type BatchOptions struct {
+	// Number of requests made in parallel, default 1.
+	// The requests also wait for the rate limit of the Connecter, if set, see SetRateLimit.
+	Concurrency int
+
+	// OnProgress, if set, is called after every request with the number of done requests,
+	// the total number of requests and the error of the request, if any.
+	OnProgress func(done, total int, err error)
+}
+
+// BatchError represents the failed requests of a batch, keyed by the request index.
+// The other requests of the batch are still done.
+type BatchError struct {
+	Total  int
+	Errors map[int]error
+}
+
+// Error returns the number of failed requests and the first failed request error.
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return fmt.Sprintf("%v of %v batch requests failed, request %v : %v", len(e.Errors), e.Total, indexes[0], e.Errors[indexes[0]])
+}
+
+// CandlesBatchResult represents the result of one request of a candles batch.
+type CandlesBatchResult struct {
+	Request  CandlesRequest
+	Response []CandlesResponse
+	Err      error
+}
+
+// GetCandlesBatch fetches the candles of all the requests and returns the results in the requests order.
+// If any request fails, the error is a *BatchError and the failed results have their Err set.
+func (c *Connecter) GetCandlesBatch(cReqs []CandlesRequest, opts BatchOptions) ([]CandlesBatchResult, error) {
+	results := make([]CandlesBatchResult, len(cReqs))
+	err := batch(len(cReqs), opts, func(i int) error {
+		cResp, err := c.GetCandles(cReqs[i])
+		results[i] = CandlesBatchResult{Request: cReqs[i], Response: cResp, Err: err}
+		return err
+	})
+	return results, err
+}
+
+// CurrenciesPredictionsHistoryBatchResult represents the result of one request of a currencies predictions history batch.
+type CurrenciesPredictionsHistoryBatchResult struct {
+	Request  CurrenciesPredictionsHistoryRequest
+	Response CurrenciesPredictionsHistoryResponse
+	Err      error
+}
+
+// GetCurrenciesPredictionsHistoryBatch fetches the predictions history of all the requests and returns the results in the requests order.
+// If any request fails, the error is a *BatchError and the failed results have their Err set.
+func (c *Connecter) GetCurrenciesPredictionsHistoryBatch(cphReqs []CurrenciesPredictionsHistoryRequest, opts BatchOptions) ([]CurrenciesPredictionsHistoryBatchResult, error) {
+	results := make([]CurrenciesPredictionsHistoryBatchResult, len(cphReqs))
+	err := batch(len(cphReqs), opts, func(i int) error {
+		cphResp, err := c.GetCurrenciesPredictionsHistory(cphReqs[i])
+		results[i] = CurrenciesPredictionsHistoryBatchResult{Request: cphReqs[i], Response: cphResp, Err: err}
+		return err
+	})
+	return results, err
+}
+
+// Helper methods
+
+// batch calls fn for the indexes 0 to n-1 with opts.Concurrency workers and collects the errors in a *BatchError.
+func batch(n int, opts BatchOptions, fn func(i int) error) error {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+
+	indexes := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	batchErr := &BatchError{Total: n, Errors: make(map[int]error)}
+	done := 0
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				err := fn(i)
+				mu.Lock()
+				done++
+				if err != nil {
+					batchErr.Errors[i] = err
+				}
+				if opts.OnProgress != nil {
+					opts.OnProgress(done, n, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if len(batchErr.Errors) > 0 {
+		return batchErr
+	}
+	return nil
+}
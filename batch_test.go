@@ -0,0 +1,67 @@
+package gonomics
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestBatch tests bounded-concurrency batch fetching with partial errors.
+func TestBatch(t *testing.T) {
+	var inFlight, maxInFlight int32
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		currency := req.URL.Query().Get("currency")
+		if currency == "BAD" {
+			return newTestResponse(req, http.StatusBadRequest, ""), nil
+		}
+		return newTestResponse(req, http.StatusOK, `[{"timestamp":"2021-01-01T00:00:00Z","close":"1"}]`), nil
+	})
+
+	startTime, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+	cReqs := []CandlesRequest{
+		{Interval: "1d", Currency: "BTC", Start: startTime},
+		{Interval: "1d", Currency: "BAD", Start: startTime},
+		{Interval: "1d", Currency: "ETH", Start: startTime},
+		{Interval: "1d", Currency: "XRP", Start: startTime},
+		{Interval: "1d", Currency: "LTC", Start: startTime},
+	}
+	var mu sync.Mutex
+	var progress []int
+	results, err := c.GetCandlesBatch(cReqs, BatchOptions{Concurrency: 2, OnProgress: func(done, total int, err error) {
+		mu.Lock()
+		progress = append(progress, done)
+		mu.Unlock()
+	}})
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Errors) != 1 || batchErr.Errors[1] == nil {
+		t.Fatalf("Something is wrong here, expected batch error for request 1, got %v.", err)
+	}
+	for i, r := range results {
+		if r.Request.Currency != cReqs[i].Currency {
+			t.Errorf("Something is wrong here, result %v is for request %v.", i, r.Request.Currency)
+		}
+		if (i == 1) != (r.Err != nil) || (i != 1 && len(r.Response) != 1) {
+			t.Errorf("Something is wrong here, unexpected result %v.", r)
+		}
+	}
+	if len(progress) != len(cReqs) || progress[len(progress)-1] != len(cReqs) {
+		t.Errorf("Something is wrong here, unexpected progress %v.", progress)
+	}
+	if m := atomic.LoadInt32(&maxInFlight); m > 2 {
+		t.Errorf("Something is wrong here, expected at most 2 parallel requests, got %v.", m)
+	}
+}
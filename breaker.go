@@ -0,0 +1,259 @@
+package gonomics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Circuit Breaker.
+
+// Circuit states.
+const (
+	CircuitClosed   string = "closed"
+	CircuitOpen     string = "open"
+	CircuitHalfOpen string = "half-open"
+)
+
+// ErrCircuitOpen is returned, without calling the server, while the circuit of the endpoint is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitBreaker stops calling an endpoint of the server once it fails too much, and fails fast with ErrCircuitOpen instead.
+// After OpenDuration, the circuit is half-open and lets HalfOpenRequests probe requests through,
+// whose success closes the circuit and failure opens it again.
+// Failures are server errors, too many requests responses and network errors, user errors like bad request are not.
+// The zero value is usable, with the zero fields below using their default, except ConsecutiveFailures and ErrorRate
+// which are disabled.
+// Cancelled calls, calls without an available pool key and calls stopped by the usage budget count as neither failure nor success.
+type CircuitBreaker struct {
+	// Consecutive failures opening the circuit, default 5, zero disables it.
+	ConsecutiveFailures int
+	// Failure rate of the last Window requests opening the circuit, like 0.5, zero disables it.
+	ErrorRate float64
+	// Number of last requests for ErrorRate, default 20 if zero.
+	Window int
+	// Minimum number of requests in the window before ErrorRate applies, default 10 if zero.
+	MinRequests int
+	// How long the circuit stays open before probing, default 30 seconds if zero.
+	OpenDuration time.Duration
+	// Number of probe requests in flight while half-open, default 1 if zero.
+	HalfOpenRequests int
+	// ServeStale serves the cached response, even expired, while the circuit is open, if the cache is enabled.
+	ServeStale bool
+	// OnStateChange, if set, is called on every state change of an endpoint circuit.
+	OnStateChange func(endpoint, from, to string)
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	now      func() time.Time
+}
+
+// circuit is the state of one endpoint.
+type circuit struct {
+	state       string
+	openedAt    time.Time
+	consecutive int
+	outcomes    []bool
+	next        int
+	probes      int
+}
+
+// NewCircuitBreaker creates a CircuitBreaker with the default thresholds.
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		ConsecutiveFailures: 5,
+		Window:              20,
+		MinRequests:         10,
+		OpenDuration:        30 * time.Second,
+		HalfOpenRequests:    1,
+		circuits:            make(map[string]*circuit),
+		now:                 time.Now,
+	}
+}
+
+// SetCircuitBreaker makes the Connecter call the server through the circuit breaker, nil disables it.
+func (c *Connecter) SetCircuitBreaker(b *CircuitBreaker) {
+	c.breaker = b
+}
+
+// State returns the circuit state of the endpoint.
+func (b *CircuitBreaker) State(endpoint string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cb := b.circuits[endpoint]
+	if cb == nil {
+		return CircuitClosed
+	}
+	if cb.state == CircuitOpen && b.reopenable(cb) {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+// Helper methods
+
+// allow returns ErrCircuitOpen if the endpoint must not be called now.
+// Every allowed call must be followed by done or release.
+func (b *CircuitBreaker) allow(endpoint string) error {
+	b.mu.Lock()
+	cb := b.circuit(endpoint)
+	from := cb.state
+	if cb.state == CircuitOpen && b.reopenable(cb) {
+		cb.state = CircuitHalfOpen
+		cb.probes = 0
+	}
+	var err error
+	switch {
+	case cb.state == CircuitOpen:
+		err = fmt.Errorf("%w : %v", ErrCircuitOpen, endpoint)
+	case cb.state == CircuitHalfOpen && cb.probes >= b.halfOpenRequests():
+		err = fmt.Errorf("%w : %v, half-open", ErrCircuitOpen, endpoint)
+	case cb.state == CircuitHalfOpen:
+		cb.probes++
+	}
+	to := cb.state
+	b.mu.Unlock()
+	b.changed(endpoint, from, to)
+	return err
+}
+
+// release releases an allowed call which did not call the server.
+func (b *CircuitBreaker) release(endpoint string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cb := b.circuit(endpoint); cb.state == CircuitHalfOpen && cb.probes > 0 {
+		cb.probes--
+	}
+}
+
+// done records the outcome of an allowed call, and opens or closes the circuit.
+// Neutral outcomes only release the call.
+func (b *CircuitBreaker) done(endpoint string, resp *http.Response, err error) {
+	if neutral(err) {
+		b.release(endpoint)
+		return
+	}
+	failed := err != nil || resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+	b.mu.Lock()
+	cb := b.circuit(endpoint)
+	from := cb.state
+	if cb.state == CircuitHalfOpen {
+		cb.probes--
+		if failed {
+			b.open(cb)
+		} else {
+			*cb = circuit{state: CircuitClosed}
+		}
+	} else if cb.state == CircuitClosed {
+		b.record(cb, failed)
+	}
+	to := cb.state
+	b.mu.Unlock()
+	b.changed(endpoint, from, to)
+}
+
+// record records the outcome in the closed circuit, and opens it if a threshold is reached.
+func (b *CircuitBreaker) record(cb *circuit, failed bool) {
+	if failed {
+		cb.consecutive++
+	} else {
+		cb.consecutive = 0
+	}
+	if window := b.window(); len(cb.outcomes) < window {
+		cb.outcomes = append(cb.outcomes, failed)
+	} else {
+		cb.outcomes[cb.next] = failed
+		cb.next = (cb.next + 1) % window
+	}
+
+	if b.ConsecutiveFailures > 0 && cb.consecutive >= b.ConsecutiveFailures {
+		b.open(cb)
+		return
+	}
+	if b.ErrorRate > 0 && len(cb.outcomes) >= b.minRequests() {
+		failures := 0
+		for _, f := range cb.outcomes {
+			if f {
+				failures++
+			}
+		}
+		if float64(failures)/float64(len(cb.outcomes)) >= b.ErrorRate {
+			b.open(cb)
+		}
+	}
+}
+
+// open opens the circuit and resets its counters.
+func (b *CircuitBreaker) open(cb *circuit) {
+	*cb = circuit{state: CircuitOpen, openedAt: b.clock()}
+}
+
+// circuit returns the circuit of the endpoint. Lock must be held by the caller.
+func (b *CircuitBreaker) circuit(endpoint string) *circuit {
+	if b.circuits == nil {
+		b.circuits = make(map[string]*circuit)
+	}
+	cb, ok := b.circuits[endpoint]
+	if !ok {
+		cb = &circuit{state: CircuitClosed}
+		b.circuits[endpoint] = cb
+	}
+	return cb
+}
+
+// reopenable reports whether the open circuit stayed open long enough to be probed.
+func (b *CircuitBreaker) reopenable(cb *circuit) bool {
+	d := b.OpenDuration
+	if d <= 0 {
+		d = 30 * time.Second
+	}
+	return !b.clock().Before(cb.openedAt.Add(d))
+}
+
+// halfOpenRequests returns the number of probe requests, or its default.
+func (b *CircuitBreaker) halfOpenRequests() int {
+	if b.HalfOpenRequests <= 0 {
+		return 1
+	}
+	return b.HalfOpenRequests
+}
+
+// minRequests returns the minimum number of requests for ErrorRate, or its default.
+func (b *CircuitBreaker) minRequests() int {
+	if b.MinRequests <= 0 {
+		return 10
+	}
+	return b.MinRequests
+}
+
+// window returns the number of last requests for ErrorRate, or its default.
+func (b *CircuitBreaker) window() int {
+	if b.Window <= 0 {
+		return 20
+	}
+	return b.Window
+}
+
+// clock returns the current time.
+func (b *CircuitBreaker) clock() time.Time {
+	if b.now == nil {
+		return time.Now()
+	}
+	return b.now()
+}
+
+// changed calls OnStateChange if the state changed.
+func (b *CircuitBreaker) changed(endpoint, from, to string) {
+	if from != to && b.OnStateChange != nil {
+		b.OnStateChange(endpoint, from, to)
+	}
+}
+
+// neutral reports whether the call failed without telling anything about the server,
+// because it was cancelled by the caller, no pool key could make it or the usage budget stopped its retry.
+func neutral(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, ErrNoKeyAvailable) || errors.Is(err, ErrBudgetExceeded)
+}
@@ -0,0 +1,153 @@
+package gonomics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker tests opening, failing fast, stale serving, half-open probing and closing of the circuit.
+func TestCircuitBreaker(t *testing.T) {
+	calls := 0
+	status := http.StatusOK
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return newTestResponse(req, status, `[{"id":"binance","base":"BTC","quote":"USDT"}]`), nil
+	})
+	c.EnableCache(10)
+	c.SetCacheTTL(EndpointMarkets, time.Nanosecond)
+
+	now := time.Now()
+	b := NewCircuitBreaker()
+	b.ConsecutiveFailures = 2
+	b.now = func() time.Time { return now }
+	var changes []string
+	b.OnStateChange = func(endpoint, from, to string) {
+		changes = append(changes, from+">"+to)
+	}
+	c.SetCircuitBreaker(b)
+
+	// Cached response, expired right away.
+	if _, err := c.GetMarkets(MarketsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	status = http.StatusServiceUnavailable
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetMarkets(MarketsRequest{}); err == nil {
+			t.Fatal("Something is wrong here, expected server error.")
+		}
+	}
+	if b.State(EndpointMarkets) != CircuitOpen {
+		t.Fatalf("Something is wrong here, expected open circuit, got %v.", b.State(EndpointMarkets))
+	}
+
+	calls = 0
+	if _, err := c.GetMarkets(MarketsRequest{}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Something is wrong here, expected circuit open error, got %v.", err)
+	}
+	b.ServeStale = true
+	if mResp, err := c.GetMarkets(MarketsRequest{}); err != nil || len(mResp) != 1 {
+		t.Errorf("Something is wrong here, expected stale response, got %v, %v.", mResp, err)
+	}
+	if calls != 0 {
+		t.Errorf("Something is wrong here, expected no server call while open, got %v.", calls)
+	}
+
+	// Failed probe opens the circuit again, successful probe closes it.
+	now = now.Add(b.OpenDuration)
+	b.ServeStale = false
+	if _, err := c.GetMarkets(MarketsRequest{}); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Something is wrong here, expected failed probe, got %v.", err)
+	}
+	now = now.Add(b.OpenDuration)
+	status = http.StatusOK
+	if _, err := c.GetMarkets(MarketsRequest{}); err != nil {
+		t.Error(err)
+	}
+	if b.State(EndpointMarkets) != CircuitClosed {
+		t.Errorf("Something is wrong here, expected closed circuit, got %v.", b.State(EndpointMarkets))
+	}
+
+	want := []string{"closed>open", "open>half-open", "half-open>open", "open>half-open", "half-open>closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("Something is wrong here, expected state changes %v, got %v.", want, changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("Something is wrong here, expected state changes %v, got %v.", want, changes)
+			break
+		}
+	}
+
+	// User errors do not open the circuit.
+	status = http.StatusBadRequest
+	for i := 0; i < 3; i++ {
+		c.GetGlobalTicker(GlobalTickerRequest{})
+	}
+	if b.State(EndpointGlobalTicker) != CircuitClosed {
+		t.Errorf("Something is wrong here, expected closed circuit on user errors, got %v.", b.State(EndpointGlobalTicker))
+	}
+}
+
+// TestCircuitBreakerCancelled tests that cancelled calls neither close nor reset the circuit.
+func TestCircuitBreakerCancelled(t *testing.T) {
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		return newTestResponse(req, http.StatusServiceUnavailable, ""), nil
+	})
+	now := time.Now()
+	b := NewCircuitBreaker()
+	b.ConsecutiveFailures = 2
+	b.now = func() time.Time { return now }
+	c.SetCircuitBreaker(b)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Cancelled call between two failures does not reset the consecutive failures.
+	c.GetMarkets(MarketsRequest{})
+	if _, err := c.WithContext(ctx).GetMarkets(MarketsRequest{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Something is wrong here, expected context canceled error, got %v.", err)
+	}
+	c.GetMarkets(MarketsRequest{})
+	if b.State(EndpointMarkets) != CircuitOpen {
+		t.Fatalf("Something is wrong here, expected open circuit, got %v.", b.State(EndpointMarkets))
+	}
+
+	// Cancelled probe does not close the circuit, and releases its probe slot.
+	now = now.Add(b.OpenDuration)
+	if _, err := c.WithContext(ctx).GetMarkets(MarketsRequest{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Something is wrong here, expected context canceled error, got %v.", err)
+	}
+	if b.State(EndpointMarkets) != CircuitHalfOpen {
+		t.Errorf("Something is wrong here, expected half-open circuit, got %v.", b.State(EndpointMarkets))
+	}
+	if _, err := c.GetMarkets(MarketsRequest{}); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Something is wrong here, expected probe to call the server, got %v.", err)
+	}
+	if b.State(EndpointMarkets) != CircuitOpen {
+		t.Errorf("Something is wrong here, expected open circuit after failed probe, got %v.", b.State(EndpointMarkets))
+	}
+}
+
+// TestCircuitBreakerZeroValue tests that a CircuitBreaker literal works without NewCircuitBreaker.
+func TestCircuitBreakerZeroValue(t *testing.T) {
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(req, http.StatusServiceUnavailable, ""), nil
+	})
+	b := &CircuitBreaker{ConsecutiveFailures: 2}
+	c.SetCircuitBreaker(b)
+	for i := 0; i < 3; i++ {
+		c.GetMarkets(MarketsRequest{})
+	}
+	if b.State(EndpointMarkets) != CircuitOpen {
+		t.Errorf("Something is wrong here, expected open circuit, got %v.", b.State(EndpointMarkets))
+	}
+}
@@ -0,0 +1,227 @@
+package gonomics
+
+import (
+	"bytes"
+	"container/list"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Response Cache.
+
+// defaultCacheTTLs are the default cache TTLs of the slow changing endpoints.
+// Endpoints not listed here are not cached, unless the TTL is set with SetCacheTTL.
+var defaultCacheTTLs = map[string]time.Duration{
+	EndpointCurrenciesMetadata: 24 * time.Hour,
+	EndpointExchangesMetadata:  24 * time.Hour,
+	EndpointMarkets:            time.Hour,
+	EndpointExchangeRates:      10 * time.Minute,
+}
+
+// CacheStats represents the statistics of the response cache.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+// responseCache is a size bounded LRU cache of the server responses with per endpoint TTLs.
+type responseCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttls       map[string]time.Duration
+	ll         *list.List
+	items      map[string]*list.Element
+	stats      CacheStats
+}
+
+// cacheEntry is one cached server response.
+type cacheEntry struct {
+	key      string
+	endpoint string
+	header   http.Header
+	body     []byte
+	expires  time.Time
+}
+
+// EnableCache enables the in-memory response cache, holding at most maxEntries responses
+// and evicting the least recently used ones. The slow changing endpoints, currencies and exchanges metadata,
+// markets and exchange rates, are cached by default, use SetCacheTTL to change TTL of any endpoint.
+func (c *Connecter) EnableCache(maxEntries int) {
+	ttls := make(map[string]time.Duration, len(defaultCacheTTLs))
+	for e, ttl := range defaultCacheTTLs {
+		ttls[e] = ttl
+	}
+	c.cache = &responseCache{
+		maxEntries: maxEntries,
+		ttls:       ttls,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// DisableCache disables the response cache and drops all the cached responses.
+func (c *Connecter) DisableCache() {
+	c.cache = nil
+}
+
+// SetCacheTTL sets how long the responses of the endpoint, like EndpointMarkets, are cached.
+// TTL 0 disables caching of the endpoint. It has no effect if the cache is not enabled.
+func (c *Connecter) SetCacheTTL(endpoint string, ttl time.Duration) {
+	if c.cache == nil {
+		return
+	}
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	c.cache.ttls[endpoint] = ttl
+}
+
+// InvalidateCache drops the cached responses of the endpoints, or all the cached responses if no endpoint is given.
+func (c *Connecter) InvalidateCache(endpoints ...string) {
+	if c.cache == nil {
+		return
+	}
+	c.cache.invalidate(endpoints...)
+}
+
+// CacheStats returns the hit, miss and eviction statistics of the response cache.
+func (c *Connecter) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	stats := c.cache.stats
+	stats.Entries = c.cache.ll.Len()
+	return stats
+}
+
+// Helper methods
+
+// cacheKey returns the cache key of the request, the endpoint with the normalized query excluding the api key.
+func cacheKey(req *http.Request) string {
+	q := req.URL.Query()
+	q.Del("key")
+	return endpoint(req) + "?" + q.Encode()
+}
+
+// get returns the cached response of the request, if it is cached and not expired.
+func (rc *responseCache) get(req *http.Request) (*http.Response, bool) {
+	if rc == nil {
+		return nil, false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.ttls[endpoint(req)] <= 0 {
+		return nil, false
+	}
+
+	el, ok := rc.items[cacheKey(req)]
+	if !ok {
+		rc.stats.Misses++
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		// Expired entries are kept until evicted or replaced, to be served stale while the circuit is open.
+		rc.stats.Misses++
+		return nil, false
+	}
+	rc.ll.MoveToFront(el)
+	rc.stats.Hits++
+	return entry.response(req), true
+}
+
+// getStale returns the cached response of the request, even if it is expired.
+func (rc *responseCache) getStale(req *http.Request) (*http.Response, bool) {
+	if rc == nil {
+		return nil, false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	el, ok := rc.items[cacheKey(req)]
+	if !ok {
+		return nil, false
+	}
+	return el.Value.(*cacheEntry).response(req), true
+}
+
+// put stores the response of the request, if its endpoint is cached, and returns the response with a re-readable body.
+func (rc *responseCache) put(req *http.Request, resp *http.Response) (*http.Response, error) {
+	if rc == nil {
+		return resp, nil
+	}
+	rc.mu.Lock()
+	ttl := rc.ttls[endpoint(req)]
+	rc.mu.Unlock()
+	if ttl <= 0 {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	entry := &cacheEntry{
+		key:      cacheKey(req),
+		endpoint: endpoint(req),
+		header:   resp.Header.Clone(),
+		body:     body,
+		expires:  time.Now().Add(ttl),
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if el, ok := rc.items[entry.key]; ok {
+		rc.remove(el)
+	}
+	rc.items[entry.key] = rc.ll.PushFront(entry)
+	for rc.maxEntries > 0 && rc.ll.Len() > rc.maxEntries {
+		rc.remove(rc.ll.Back())
+		rc.stats.Evictions++
+	}
+	return resp, nil
+}
+
+// invalidate drops the cached responses of the endpoints, or all if no endpoint is given.
+func (rc *responseCache) invalidate(endpoints ...string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	drop := make(map[string]bool, len(endpoints))
+	for _, e := range endpoints {
+		drop[e] = true
+	}
+	for el := rc.ll.Front(); el != nil; {
+		next := el.Next()
+		if len(endpoints) == 0 || drop[el.Value.(*cacheEntry).endpoint] {
+			rc.remove(el)
+		}
+		el = next
+	}
+}
+
+// remove removes the list element from the cache. Lock must be held by the caller.
+func (rc *responseCache) remove(el *list.Element) {
+	rc.ll.Remove(el)
+	delete(rc.items, el.Value.(*cacheEntry).key)
+}
+
+// response returns a new http response of the cached entry for the request.
+func (entry *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       req,
+	}
+}
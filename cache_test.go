@@ -0,0 +1,66 @@
+package gonomics
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestCache tests in-memory response caching of the connector.
+func TestCache(t *testing.T) {
+	calls := 0
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return newTestResponse(req, http.StatusOK, `[{"currency":"BTC","rate":"50000","timestamp":"2021-01-01T00:00:00Z"}]`), nil
+	})
+	c.EnableCache(1)
+
+	for i := 0; i < 3; i++ {
+		erResp, err := c.GetExchangeRates(ExchangeRatesRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(erResp) != 1 || erResp[0].Rate != 50000 {
+			t.Errorf("Something is wrong here, unexpected cached response %v.", erResp)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Something is wrong here, expected 1 server call, got %v.", calls)
+	}
+	if stats := c.CacheStats(); stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("Something is wrong here, unexpected cache stats %v.", stats)
+	}
+
+	// Api key is not part of the cache key.
+	c.apiKey = "other"
+	if _, err := c.GetExchangeRates(ExchangeRatesRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Error("Something is wrong here, api key should not be part of the cache key.")
+	}
+
+	// Not cached endpoint.
+	if _, err := c.GetExchangeRatesHistory(ExchangeRatesHistoryRequest{Currency: "BTC", Start: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("Something is wrong here, expected 2 server calls, got %v.", calls)
+	}
+
+	// LRU eviction.
+	c.SetCacheTTL(EndpointMarkets, time.Hour)
+	if _, err := c.GetMarkets(MarketsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if stats := c.CacheStats(); stats.Evictions != 1 || stats.Entries != 1 {
+		t.Errorf("Something is wrong here, unexpected cache stats after eviction %v.", stats)
+	}
+
+	// Invalidation.
+	c.InvalidateCache(EndpointMarkets)
+	if stats := c.CacheStats(); stats.Entries != 0 {
+		t.Errorf("Something is wrong here, cache is not invalidated %v.", stats)
+	}
+}
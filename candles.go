@@ -65,7 +65,6 @@ func (c *Connecter) GetCandles(cReq CandlesRequest) ([]CandlesResponse, error) {
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if cReq.Interval == "" {
 		return nil, errors.New("interval is required")
 	}
@@ -89,7 +88,7 @@ func (c *Connecter) GetCandles(cReq CandlesRequest) ([]CandlesResponse, error) {
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, cReq)
 	if err != nil {
 		return nil, err
 	}
@@ -163,7 +162,6 @@ func (c *Connecter) GetExchangeCandles(ecReq ExchangeCandlesRequest) ([]Exchange
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if ecReq.Interval == "" {
 		return nil, errors.New("interval is required")
 	}
@@ -172,6 +170,9 @@ func (c *Connecter) GetExchangeCandles(ecReq ExchangeCandlesRequest) ([]Exchange
 		return nil, errors.New("exchange is required")
 	}
 	q.Add("exchange", ecReq.Exchange)
+	if err := c.checkCapability(ecReq.Exchange, CapabilityCandles); err != nil {
+		return nil, err
+	}
 	if ecReq.Market == "" {
 		return nil, errors.New("market is required")
 	}
@@ -191,7 +192,7 @@ func (c *Connecter) GetExchangeCandles(ecReq ExchangeCandlesRequest) ([]Exchange
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, ecReq)
 	if err != nil {
 		return nil, err
 	}
@@ -265,7 +266,6 @@ func (c *Connecter) GetMarketsCandles(mcReq MarketsCandlesRequest) ([]MarketsCan
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if mcReq.Interval == "" {
 		return nil, errors.New("interval is required")
 	}
@@ -293,7 +293,7 @@ func (c *Connecter) GetMarketsCandles(mcReq MarketsCandlesRequest) ([]MarketsCan
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, mcReq)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,324 @@
+package gonomics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Candles Validation.
+
+// Candle issue kinds reported by candles validation.
+const (
+	// CandleIssueOutOfOrder is for a candle with timestamp before the previous candle.
+	CandleIssueOutOfOrder string = "out-of-order"
+	// CandleIssueDuplicate is for a candle with the same timestamp as another candle.
+	CandleIssueDuplicate string = "duplicate"
+	// CandleIssueGap is for missing candles between two consecutive candles.
+	CandleIssueGap string = "gap"
+	// CandleIssueMisaligned is for a candle not spaced a multiple of interval from the previous candle.
+	CandleIssueMisaligned string = "misaligned"
+	// CandleIssueOHLC is for a candle with high lower than open, close or low, or low higher than open or close.
+	CandleIssueOHLC string = "ohlc"
+	// CandleIssueNegative is for a candle with negative price or volume.
+	CandleIssueNegative string = "negative"
+	// CandleIssuePriceOutlier is for a candle flagged as price outlier by the server.
+	CandleIssuePriceOutlier string = "price-outlier"
+	// CandleIssueVolumeOutlier is for a candle flagged as volume outlier by the server.
+	CandleIssueVolumeOutlier string = "volume-outlier"
+)
+
+// CandlesIssue represents one data-quality issue found in the candles.
+// Index is the index of the candle in the validated array.
+type CandlesIssue struct {
+	Index     int
+	Timestamp time.Time
+	Kind      string
+	Message   string
+}
+
+// CandlesReport represents the result of candles validation.
+type CandlesReport struct {
+	Interval string
+	Count    int
+	Start    time.Time
+	End      time.Time
+
+	// Number of candles missing between Start and End, as per the Interval.
+	Missing int
+
+	Issues []CandlesIssue
+}
+
+// CandlesRepairOptions represents what to repair in the candles.
+// Candles are always sorted by timestamp and de-duplicated, keeping the last candle of a timestamp.
+type CandlesRepairOptions struct {
+	// Fill gaps with flat candles at the previous close and zero volume.
+	FillGaps bool
+
+	// Remove candles flagged as price or volume outlier by the server.
+	RemoveOutliers bool
+
+	// Remove candles with OHLC inconsistencies or negative values.
+	RemoveInvalid bool
+}
+
+// Valid returns true if no issue is found in the candles.
+func (r CandlesReport) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// ValidateCandles checks the candles for timestamp order, duplicates and spacing against the interval,
+// and for OHLC invariants.
+func ValidateCandles(cResp []CandlesResponse, interval string) (CandlesReport, error) {
+	return validateCandles(fromCandles(cResp), interval)
+}
+
+// ValidateExchangeCandles checks the exchange candles for timestamp order, duplicates and spacing against the interval,
+// for OHLC invariants and for outliers flagged by the server.
+func ValidateExchangeCandles(ecResp []ExchangeCandlesResponse, interval string) (CandlesReport, error) {
+	return validateCandles(fromExchangeCandles(ecResp), interval)
+}
+
+// ValidateMarketsCandles checks the markets candles for timestamp order, duplicates and spacing against the interval,
+// for OHLC invariants and for outliers flagged by the server.
+func ValidateMarketsCandles(mcResp []MarketsCandlesResponse, interval string) (CandlesReport, error) {
+	return validateCandles(fromMarketsCandles(mcResp), interval)
+}
+
+// RepairCandles returns the repaired copy of the candles as per the options.
+func RepairCandles(cResp []CandlesResponse, interval string, opts CandlesRepairOptions) ([]CandlesResponse, error) {
+	plan, err := repairCandles(fromCandles(cResp), interval, opts)
+	if err != nil {
+		return nil, err
+	}
+	repaired := make([]CandlesResponse, 0, len(plan))
+	for _, e := range plan {
+		if e.src >= 0 {
+			repaired = append(repaired, cResp[e.src])
+			continue
+		}
+		repaired = append(repaired, CandlesResponse{
+			Timestamp: e.timestamp, Open: e.close, High: e.close, Low: e.close, Close: e.close,
+			TransparentOpen: e.close, TransparentHigh: e.close, TransparentLow: e.close, TransparentClose: e.close,
+		})
+	}
+	return repaired, nil
+}
+
+// RepairExchangeCandles returns the repaired copy of the exchange candles as per the options.
+func RepairExchangeCandles(ecResp []ExchangeCandlesResponse, interval string, opts CandlesRepairOptions) ([]ExchangeCandlesResponse, error) {
+	plan, err := repairCandles(fromExchangeCandles(ecResp), interval, opts)
+	if err != nil {
+		return nil, err
+	}
+	repaired := make([]ExchangeCandlesResponse, 0, len(plan))
+	for _, e := range plan {
+		if e.src >= 0 {
+			repaired = append(repaired, ecResp[e.src])
+			continue
+		}
+		repaired = append(repaired, ExchangeCandlesResponse{
+			Timestamp: e.timestamp, Open: e.close, High: e.close, Low: e.close, Close: e.close,
+		})
+	}
+	return repaired, nil
+}
+
+// RepairMarketsCandles returns the repaired copy of the markets candles as per the options.
+func RepairMarketsCandles(mcResp []MarketsCandlesResponse, interval string, opts CandlesRepairOptions) ([]MarketsCandlesResponse, error) {
+	plan, err := repairCandles(fromMarketsCandles(mcResp), interval, opts)
+	if err != nil {
+		return nil, err
+	}
+	repaired := make([]MarketsCandlesResponse, 0, len(plan))
+	for _, e := range plan {
+		if e.src >= 0 {
+			repaired = append(repaired, mcResp[e.src])
+			continue
+		}
+		repaired = append(repaired, MarketsCandlesResponse{
+			Timestamp: e.timestamp, Open: e.close, High: e.close, Low: e.close, Close: e.close,
+		})
+	}
+	return repaired, nil
+}
+
+// Helper methods
+
+// candle is the common form of all candle responses used for validation.
+type candle struct {
+	timestamp     time.Time
+	open          float64
+	high          float64
+	low           float64
+	close         float64
+	volume        float64
+	priceOutlier  bool
+	volumeOutlier bool
+}
+
+// inconsistent returns true if high is lower than open, close or low, or low is higher than open or close.
+func (c candle) inconsistent() bool {
+	return c.high < c.low || c.high < c.open || c.high < c.close || c.low > c.open || c.low > c.close
+}
+
+// negative returns true if any price or the volume is negative.
+func (c candle) negative() bool {
+	return c.open < 0 || c.high < 0 || c.low < 0 || c.close < 0 || c.volume < 0
+}
+
+// repairEntry is one candle of the repaired candles, either the candle at src index
+// or a filled candle if src is -1.
+type repairEntry struct {
+	src       int
+	timestamp time.Time
+	close     float64
+}
+
+// intervalDuration returns the duration of the candles interval, like "1m", "4h" or "1d".
+func intervalDuration(interval string) (time.Duration, error) {
+	if strings.HasSuffix(interval, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(interval, "d"))
+		if err != nil || days <= 0 {
+			return 0, fmt.Errorf("invalid interval %v", interval)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid interval %v", interval)
+	}
+	return d, nil
+}
+
+// validateCandles validates the candles against the interval.
+func validateCandles(cs []candle, interval string) (CandlesReport, error) {
+	step, err := intervalDuration(interval)
+	if err != nil {
+		return CandlesReport{}, err
+	}
+
+	r := CandlesReport{Interval: interval, Count: len(cs)}
+	seen := make(map[time.Time]int, len(cs))
+	for i, c := range cs {
+		issue := func(kind, format string, a ...interface{}) {
+			r.Issues = append(r.Issues, CandlesIssue{Index: i, Timestamp: c.timestamp, Kind: kind, Message: fmt.Sprintf(format, a...)})
+		}
+		if r.Start.IsZero() || c.timestamp.Before(r.Start) {
+			r.Start = c.timestamp
+		}
+		if c.timestamp.After(r.End) {
+			r.End = c.timestamp
+		}
+
+		if j, ok := seen[c.timestamp]; ok {
+			issue(CandleIssueDuplicate, "same timestamp as candle %v", j)
+		} else {
+			seen[c.timestamp] = i
+		}
+		if i > 0 {
+			prev := cs[i-1].timestamp
+			switch diff := c.timestamp.Sub(prev); {
+			case diff < 0:
+				issue(CandleIssueOutOfOrder, "timestamp is before previous candle %v", prev.Format(time.RFC3339))
+			case diff > 0 && diff%step != 0:
+				issue(CandleIssueMisaligned, "spacing %v from previous candle is not a multiple of %v", diff, interval)
+			case diff > step:
+				issue(CandleIssueGap, "%v candles missing after %v", int(diff/step)-1, prev.Format(time.RFC3339))
+			}
+		}
+
+		if c.inconsistent() {
+			issue(CandleIssueOHLC, "inconsistent ohlc %v %v %v %v", c.open, c.high, c.low, c.close)
+		}
+		if c.negative() {
+			issue(CandleIssueNegative, "negative price or volume")
+		}
+		if c.priceOutlier {
+			issue(CandleIssuePriceOutlier, "price flagged as outlier")
+		}
+		if c.volumeOutlier {
+			issue(CandleIssueVolumeOutlier, "volume flagged as outlier")
+		}
+	}
+	if len(cs) > 0 {
+		r.Missing = int(r.End.Sub(r.Start)/step) + 1 - len(seen)
+		if r.Missing < 0 {
+			r.Missing = 0
+		}
+	}
+	return r, nil
+}
+
+// repairCandles returns the plan of the repaired candles as per the options.
+func repairCandles(cs []candle, interval string, opts CandlesRepairOptions) ([]repairEntry, error) {
+	step, err := intervalDuration(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := make([]int, 0, len(cs))
+	for i, c := range cs {
+		if opts.RemoveOutliers && (c.priceOutlier || c.volumeOutlier) {
+			continue
+		}
+		if opts.RemoveInvalid && (c.inconsistent() || c.negative()) {
+			continue
+		}
+		idx = append(idx, i)
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return cs[idx[i]].timestamp.Before(cs[idx[j]].timestamp)
+	})
+
+	var plan []repairEntry
+	for _, i := range idx {
+		c := cs[i]
+		if n := len(plan); n > 0 {
+			last := plan[n-1]
+			if c.timestamp.Equal(last.timestamp) {
+				plan[n-1] = repairEntry{src: i, timestamp: c.timestamp, close: c.close}
+				continue
+			}
+			if opts.FillGaps {
+				for ts := last.timestamp.Add(step); ts.Before(c.timestamp); ts = ts.Add(step) {
+					plan = append(plan, repairEntry{src: -1, timestamp: ts, close: last.close})
+				}
+			}
+		}
+		plan = append(plan, repairEntry{src: i, timestamp: c.timestamp, close: c.close})
+	}
+	return plan, nil
+}
+
+// fromCandles returns the common form of the candles.
+func fromCandles(cResp []CandlesResponse) []candle {
+	cs := make([]candle, 0, len(cResp))
+	for _, c := range cResp {
+		cs = append(cs, candle{timestamp: c.Timestamp, open: c.Open, high: c.High, low: c.Low, close: c.Close, volume: c.Volume})
+	}
+	return cs
+}
+
+// fromExchangeCandles returns the common form of the exchange candles.
+func fromExchangeCandles(ecResp []ExchangeCandlesResponse) []candle {
+	cs := make([]candle, 0, len(ecResp))
+	for _, c := range ecResp {
+		cs = append(cs, candle{timestamp: c.Timestamp, open: c.Open, high: c.High, low: c.Low, close: c.Close, volume: c.Volume,
+			priceOutlier: c.PriceOutlier, volumeOutlier: c.VolumeOutlier})
+	}
+	return cs
+}
+
+// fromMarketsCandles returns the common form of the markets candles.
+func fromMarketsCandles(mcResp []MarketsCandlesResponse) []candle {
+	cs := make([]candle, 0, len(mcResp))
+	for _, c := range mcResp {
+		cs = append(cs, candle{timestamp: c.Timestamp, open: c.Open, high: c.High, low: c.Low, close: c.Close, volume: c.Volume,
+			priceOutlier: c.PriceOutlier, volumeOutlier: c.VolumeOutlier})
+	}
+	return cs
+}
@@ -0,0 +1,67 @@
+package gonomics
+
+import (
+	"testing"
+	"time"
+)
+
+// TestValidateCandles tests candles validation and repair functions.
+func TestValidateCandles(t *testing.T) {
+	startTime, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+	hour := time.Hour
+	ecResp := []ExchangeCandlesResponse{
+		{Timestamp: startTime, Open: 1, High: 2, Low: 1, Close: 2, Volume: 10},
+		{Timestamp: startTime.Add(hour), Open: 2, High: 1, Low: 1, Close: 2, Volume: 10},
+		{Timestamp: startTime.Add(hour), Open: 2, High: 3, Low: 2, Close: 3, Volume: 10},
+		{Timestamp: startTime.Add(4 * hour), Open: 3, High: 3, Low: 3, Close: 3, Volume: -1},
+		{Timestamp: startTime.Add(5 * hour), Open: 3, High: 9, Low: 3, Close: 9, Volume: 10, PriceOutlier: true},
+	}
+	r, err := ValidateExchangeCandles(ecResp, "1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]int)
+	for _, issue := range r.Issues {
+		kinds[issue.Kind]++
+	}
+	if kinds[CandleIssueOHLC] != 1 || kinds[CandleIssueDuplicate] != 1 || kinds[CandleIssueGap] != 1 ||
+		kinds[CandleIssueNegative] != 1 || kinds[CandleIssuePriceOutlier] != 1 {
+		t.Errorf("Something is wrong here, unexpected issues %v.", r.Issues)
+	}
+	if r.Valid() || r.Missing != 2 || r.Count != 5 {
+		t.Errorf("Something is wrong here, unexpected report %v.", r)
+	}
+	if _, err := ValidateExchangeCandles(ecResp, "1x"); err == nil {
+		t.Error("Something is wrong here, validation with invalid interval should fail.")
+	}
+
+	repaired, err := RepairExchangeCandles(ecResp, "1h", CandlesRepairOptions{FillGaps: true, RemoveOutliers: true, RemoveInvalid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repaired) != 2 || repaired[1].Close != 3 {
+		t.Errorf("Something is wrong here, unexpected repaired candles %v.", repaired)
+	}
+	repaired, err = RepairExchangeCandles(ecResp, "1h", CandlesRepairOptions{FillGaps: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repaired) != 6 || repaired[2].Close != 3 || repaired[2].Volume != 0 {
+		t.Errorf("Something is wrong here, gaps are not filled %v.", repaired)
+	}
+	r, err = ValidateExchangeCandles(repaired, "1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Missing != 0 {
+		t.Errorf("Something is wrong here, repaired candles still have %v missing.", r.Missing)
+	}
+
+	cr, err := ValidateCandles([]CandlesResponse{{Timestamp: startTime.Add(24 * hour)}, {Timestamp: startTime}}, "1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cr.Issues) != 1 || cr.Issues[0].Kind != CandleIssueOutOfOrder {
+		t.Errorf("Something is wrong here, unexpected issues %v.", cr.Issues)
+	}
+}
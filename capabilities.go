@@ -0,0 +1,154 @@
+package gonomics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Exchange Capabilities.
+
+// Exchange capabilities as given in the exchanges metadata.
+const (
+	CapabilityMarkets           string = "markets"
+	CapabilityTrades            string = "trades"
+	CapabilityTradesByTimestamp string = "trades by timestamp"
+	CapabilityTradesSnapshot    string = "trades snapshot"
+	CapabilityOrdersSnapshot    string = "orders snapshot"
+	CapabilityCandles           string = "candles"
+	CapabilityTicker            string = "ticker"
+)
+
+// ErrCapabilityNotSupported is returned, wrapped with the exchange and capability details,
+// when the exchange does not support the capability needed by the request.
+var ErrCapabilityNotSupported = errors.New("capability not supported by exchange")
+
+// CapabilityRegistry holds the capabilities of the exchanges from the exchanges metadata.
+type CapabilityRegistry struct {
+	mu        sync.RWMutex
+	exchanges map[string]ExchangesMetadataResponse
+}
+
+// NewCapabilityRegistry creates a brand new CapabilityRegistry from the exchanges metadata.
+func NewCapabilityRegistry(emResp []ExchangesMetadataResponse) *CapabilityRegistry {
+	r := &CapabilityRegistry{}
+	r.Load(emResp)
+	return r
+}
+
+// LoadCapabilities fetches the exchanges metadata from the server once, and makes GetTrades, GetOrdersSnapshot
+// and GetExchangeCandles fail fast with ErrCapabilityNotSupported for exchanges not supporting them.
+func (c *Connecter) LoadCapabilities() (*CapabilityRegistry, error) {
+	emResp, err := c.GetExchangesMetadata(ExchangesMetadataRequest{})
+	if err != nil {
+		return nil, err
+	}
+	r := NewCapabilityRegistry(emResp)
+	c.SetCapabilities(r)
+	return r, nil
+}
+
+// SetCapabilities sets the CapabilityRegistry used to check the exchange capabilities before requests.
+// Set nil to disable the checks.
+func (c *Connecter) SetCapabilities(r *CapabilityRegistry) {
+	c.capabilities = r
+}
+
+// Load replaces the capabilities of the registry with the exchanges metadata.
+func (r *CapabilityRegistry) Load(emResp []ExchangesMetadataResponse) {
+	exchanges := make(map[string]ExchangesMetadataResponse, len(emResp))
+	for _, em := range emResp {
+		exchanges[strings.ToLower(em.ID)] = em
+	}
+	r.mu.Lock()
+	r.exchanges = exchanges
+	r.mu.Unlock()
+}
+
+// Supports returns true if the exchange is integrated and supports the capability.
+func (r *CapabilityRegistry) Supports(exchange, capability string) bool {
+	r.mu.RLock()
+	em, ok := r.exchanges[strings.ToLower(exchange)]
+	r.mu.RUnlock()
+	return ok && em.Integrated && hasCapability(em, capability)
+}
+
+// Exchanges returns the sorted ids of the exchanges supporting the capability.
+func (r *CapabilityRegistry) Exchanges(capability string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ids []string
+	for _, em := range r.exchanges {
+		if em.Integrated && hasCapability(em, capability) {
+			ids = append(ids, em.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Markets returns the base / quote markets, from the given markets, whose exchange supports the capability.
+// For example, markets of BTC / USDT on exchanges supporting CapabilityOrdersSnapshot.
+func (r *CapabilityRegistry) Markets(capability, base, quote string, mResp []MarketsResponse) []MarketsResponse {
+	var markets []MarketsResponse
+	for _, m := range mResp {
+		if !strings.EqualFold(m.Base, base) || !strings.EqualFold(m.Quote, quote) {
+			continue
+		}
+		if r.Supports(m.Exchange, capability) {
+			markets = append(markets, m)
+		}
+	}
+	return markets
+}
+
+// check returns error if the exchange is known to the registry and does not support the capability.
+// Exchanges not known to the registry are not checked.
+func (r *CapabilityRegistry) check(exchange, capability string) error {
+	r.mu.RLock()
+	em, ok := r.exchanges[strings.ToLower(exchange)]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	if !em.Integrated {
+		return fmt.Errorf("exchange %v is not integrated: %w", exchange, ErrCapabilityNotSupported)
+	}
+	if !hasCapability(em, capability) {
+		return fmt.Errorf("exchange %v does not support %v: %w", exchange, capability, ErrCapabilityNotSupported)
+	}
+	return nil
+}
+
+// Helper methods
+
+// checkCapability returns error if the capabilities are loaded and the exchange does not support the capability.
+func (c *Connecter) checkCapability(exchange, capability string) error {
+	if c.capabilities == nil {
+		return nil
+	}
+	return c.capabilities.check(exchange, capability)
+}
+
+// hasCapability returns the capability flag of the exchange metadata.
+func hasCapability(em ExchangesMetadataResponse, capability string) bool {
+	switch capability {
+	case CapabilityMarkets:
+		return em.CapabilityMarkets
+	case CapabilityTrades:
+		return em.CapabilityTrades
+	case CapabilityTradesByTimestamp:
+		return em.CapabilityTradesByTimestamp
+	case CapabilityTradesSnapshot:
+		return em.CapabilityTradesSnapshot
+	case CapabilityOrdersSnapshot:
+		return em.CapabilityOrdersSnapshot
+	case CapabilityCandles:
+		return em.CapabilityCandles
+	case CapabilityTicker:
+		return em.CapabilityTicker
+	}
+	return false
+}
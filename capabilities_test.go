@@ -0,0 +1,43 @@
+package gonomics
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestCapabilityRegistry tests exchange capability registry and fail fast of requests.
+func TestCapabilityRegistry(t *testing.T) {
+	c := New(demoAPIKey)
+	c.SetCapabilities(NewCapabilityRegistry([]ExchangesMetadataResponse{
+		{ID: "binance", Integrated: true, CapabilityTrades: true, CapabilityOrdersSnapshot: true, CapabilityCandles: true},
+		{ID: "kraken", Integrated: true, CapabilityTrades: true},
+		{ID: "dead", CapabilityOrdersSnapshot: true},
+	}))
+
+	if exchanges := c.capabilities.Exchanges(CapabilityOrdersSnapshot); !reflect.DeepEqual(exchanges, []string{"binance"}) {
+		t.Errorf("Something is wrong here, unexpected exchanges %v.", exchanges)
+	}
+	markets := c.capabilities.Markets(CapabilityOrdersSnapshot, "BTC", "USDT", []MarketsResponse{
+		{Exchange: "binance", Market: "BTCUSDT", Base: "BTC", Quote: "USDT"},
+		{Exchange: "kraken", Market: "XBTUSDT", Base: "BTC", Quote: "USDT"},
+		{Exchange: "binance", Market: "ETHUSDT", Base: "ETH", Quote: "USDT"},
+	})
+	if len(markets) != 1 || markets[0].Market != "BTCUSDT" {
+		t.Errorf("Something is wrong here, unexpected markets %v.", markets)
+	}
+
+	if _, err := c.GetOrdersSnapshot(OrdersSnapshotRequest{Exchange: "kraken", Market: "XBTUSDT"}); !errors.Is(err, ErrCapabilityNotSupported) {
+		t.Errorf("Something is wrong here, expected ErrCapabilityNotSupported, got %v.", err)
+	}
+	if _, err := c.GetOrdersSnapshot(OrdersSnapshotRequest{Exchange: "dead", Market: "BTCUSDT"}); !errors.Is(err, ErrCapabilityNotSupported) {
+		t.Errorf("Something is wrong here, expected ErrCapabilityNotSupported, got %v.", err)
+	}
+	if _, err := c.GetExchangeCandles(ExchangeCandlesRequest{Interval: "1d", Exchange: "kraken", Market: "XBTUSDT"}); !errors.Is(err, ErrCapabilityNotSupported) {
+		t.Errorf("Something is wrong here, expected ErrCapabilityNotSupported, got %v.", err)
+	}
+	if _, err := c.GetTrades(TradesRequest{Exchange: "binance", Market: "BTCUSDT", From: time.Now()}); !errors.Is(err, ErrCapabilityNotSupported) {
+		t.Errorf("Something is wrong here, expected ErrCapabilityNotSupported, got %v.", err)
+	}
+}
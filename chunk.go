@@ -0,0 +1,82 @@
+package gonomics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Ids Chunking.
+
+// defaultIdsChunkSize is the default maximum number of ids in one request.
+const defaultIdsChunkSize = 100
+
+// SetIdsChunkSize sets the maximum number of ids in one request, default 100.
+// Requests with more ids, in Currencies Ticker, Currencies Metadata, Currencies Sparkline, Exchanges Ticker
+// and Currencies Predictions Ticker, are split into sub-requests, whose results are merged in the ids order.
+// Paged and csv format requests with more ids can not be split, and fail with an error instead.
+func (c *Connecter) SetIdsChunkSize(size int) {
+	c.idsChunkSize = size
+}
+
+// Helper methods
+
+// getChunked splits the request ids into sub-requests of the Connecter ids chunk size, if they do not fit
+// in one request, and reports whether it did. get does the sub-request of the chunk ids and appends its results,
+// merge sorts the appended results in the ids order once all the sub-requests are done.
+// Paged requests, with page or perPage, and csv format requests can not be split, and fail with an error instead.
+func (c *Connecter) getChunked(ids []string, page, perPage int, format string, get func(ids []string) error, merge func()) (bool, error) {
+	chunks, err := c.chunkIds(ids, page == 0 && perPage == 0 && (format == "" || format == "json"))
+	if err != nil || chunks == nil {
+		return false, err
+	}
+	for _, chunk := range chunks {
+		if err := get(chunk); err != nil {
+			return true, err
+		}
+	}
+	merge()
+	return true, nil
+}
+
+// chunkIds splits the ids into chunks of the Connecter ids chunk size.
+// It returns nil if the ids fit in one request, and an error if they do not but the request is not splittable,
+// because it is paged or in csv format.
+func (c *Connecter) chunkIds(ids []string, splittable bool) ([][]string, error) {
+	size := c.idsChunkSize
+	if size <= 0 {
+		size = defaultIdsChunkSize
+	}
+	if len(ids) <= size {
+		return nil, nil
+	}
+	if !splittable {
+		return nil, fmt.Errorf("%v ids can not be split into requests of %v ids, as the request is paged or in csv format", len(ids), size)
+	}
+	var chunks [][]string
+	for len(ids) > size {
+		chunks = append(chunks, ids[:size])
+		ids = ids[size:]
+	}
+	return append(chunks, ids), nil
+}
+
+// sortByIds sorts the slice of results in the order of the ids, results of unknown ids go last.
+// id returns the id of the ith result.
+func sortByIds(slice interface{}, ids []string, id func(i int) string) {
+	order := make(map[string]int, len(ids))
+	for i, v := range ids {
+		if _, ok := order[strings.ToUpper(v)]; !ok {
+			order[strings.ToUpper(v)] = i
+		}
+	}
+	rank := func(i int) int {
+		if r, ok := order[strings.ToUpper(id(i))]; ok {
+			return r
+		}
+		return len(ids)
+	}
+	sort.SliceStable(slice, func(i, j int) bool {
+		return rank(i) < rank(j)
+	})
+}
@@ -0,0 +1,63 @@
+package gonomics
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestIdsChunking tests splitting of long ids into sub-requests and merging in the ids order.
+func TestIdsChunking(t *testing.T) {
+	var requests []string
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		ids := strings.Split(req.URL.Query().Get("ids"), ",")
+		requests = append(requests, req.URL.Query().Get("ids"))
+		var items []string
+		// Server returns the ids in reverse order.
+		for i := len(ids) - 1; i >= 0; i-- {
+			items = append(items, fmt.Sprintf(`{"id":"%v"}`, ids[i]))
+		}
+		return newTestResponse(req, http.StatusOK, "["+strings.Join(items, ",")+"]"), nil
+	})
+	c.SetIdsChunkSize(2)
+
+	ids := []string{"BTC", "ETH", "XRP", "LTC", "ADA"}
+	ctResp, err := c.GetCurrenciesTicker(CurrenciesTickerRequest{Ids: ids})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 3 || requests[2] != "ADA" {
+		t.Errorf("Something is wrong here, unexpected sub-requests %v.", requests)
+	}
+	if len(ctResp) != len(ids) {
+		t.Fatalf("Something is wrong here, expected %v results, got %v.", len(ids), len(ctResp))
+	}
+	for i, ct := range ctResp {
+		if ct.ID != ids[i] {
+			t.Errorf("Something is wrong here, expected %v at %v, got %v.", ids[i], i, ct.ID)
+		}
+	}
+
+	// Short ids are not split.
+	requests = nil
+	if _, err := c.GetCurrenciesPredictionsTicker(CurrenciesPredictionsTickerRequest{Ids: ids[:2]}); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("Something is wrong here, expected 1 request, got %v.", requests)
+	}
+
+	// Paged and csv format requests with long ids are not split.
+	requests = nil
+	if _, err := c.GetExchangesTicker(ExchangesTickerRequest{Ids: ids, PerPage: 10}); err == nil {
+		t.Error("Something is wrong here, expected error for paged request with long ids.")
+	}
+	if _, err := c.GetCurrenciesMetadata(CurrenciesMetadataRequest{Ids: ids, Format: "csv", FileNameWithPath: "metadata.csv"}); err == nil {
+		t.Error("Something is wrong here, expected error for csv request with long ids.")
+	}
+	if len(requests) != 0 {
+		t.Errorf("Something is wrong here, expected no request, got %v.", requests)
+	}
+}
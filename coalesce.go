@@ -0,0 +1,95 @@
+package gonomics
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Request Coalescing.
+
+// coalescer makes identical in-flight requests, same endpoint and normalized query, share one server call.
+type coalescer struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// coalescedCall is one in-flight server call shared by the waiting requests.
+type coalescedCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+
+	entry *cacheEntry
+	err   error
+}
+
+// EnableCoalescing makes identical in-flight requests share one server call and its result.
+// Each caller still honours its own context cancellation, the shared server call is cancelled
+// only once all its callers have cancelled.
+func (c *Connecter) EnableCoalescing() {
+	c.coalescer = &coalescer{calls: make(map[string]*coalescedCall)}
+}
+
+// DisableCoalescing makes every request do its own server call.
+func (c *Connecter) DisableCoalescing() {
+	c.coalescer = nil
+}
+
+// Helper methods
+
+// do makes the request using fetch, sharing the server call with the identical in-flight requests.
+func (co *coalescer) do(req *http.Request, fetch func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	key := cacheKey(req)
+
+	co.mu.Lock()
+	call, ok := co.calls[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		call = &coalescedCall{done: make(chan struct{}), cancel: cancel}
+		co.calls[key] = call
+		go co.run(key, call, req.WithContext(ctx), fetch)
+	}
+	call.waiters++
+	co.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return nil, call.err
+		}
+		return call.entry.response(req), nil
+	case <-req.Context().Done():
+		co.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			if co.calls[key] == call {
+				delete(co.calls, key)
+			}
+		}
+		co.mu.Unlock()
+		return nil, req.Context().Err()
+	}
+}
+
+// run makes the shared server call and stores its result in the call.
+func (co *coalescer) run(key string, call *coalescedCall, req *http.Request, fetch func(*http.Request) (*http.Response, error)) {
+	defer call.cancel()
+	resp, err := fetch(req)
+	if err == nil {
+		var body []byte
+		body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		call.entry = &cacheEntry{key: key, endpoint: endpoint(req), header: resp.Header, body: body}
+	}
+	call.err = err
+
+	co.mu.Lock()
+	if co.calls[key] == call {
+		delete(co.calls, key)
+	}
+	co.mu.Unlock()
+	close(call.done)
+}
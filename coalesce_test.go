@@ -0,0 +1,77 @@
+package gonomics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCoalescing tests sharing of one server call by identical in-flight requests.
+func TestCoalescing(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return newTestResponse(req, http.StatusOK, `[{"currency":"BTC","rate":"50000","timestamp":"2021-01-01T00:00:00Z"}]`), nil
+	})
+	c.EnableCoalescing()
+
+	// Cancelled caller returns immediately, others still get the shared result.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := c.WithContext(ctx).GetExchangeRates(ExchangeRatesRequest{})
+		cancelled <- err
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			erResp, err := c.GetExchangeRates(ExchangeRatesRequest{})
+			if err == nil && (len(erResp) != 1 || erResp[0].Rate != 50000) {
+				err = errors.New("unexpected response")
+			}
+			errs <- err
+		}()
+	}
+
+	// Wait for all the callers to join the shared call.
+	deadline := time.Now().Add(5 * time.Second)
+	for waiters := 0; waiters < 11; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Something is wrong here, expected 11 callers to join the shared call, got %v.", waiters)
+		}
+		time.Sleep(time.Millisecond)
+		c.coalescer.mu.Lock()
+		waiters = 0
+		for _, call := range c.coalescer.calls {
+			waiters += call.waiters
+		}
+		c.coalescer.mu.Unlock()
+	}
+
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("Something is wrong here, expected context canceled error, got %v.", err)
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Something is wrong here, expected identical requests to share the server call, got %v calls.", n)
+	}
+}
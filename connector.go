@@ -1,15 +1,20 @@
 package gonomics
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 )
 
 // API endpoints for Nomics.
 const (
-	apiServerURL string = "https://api.nomics.com/v1"
+	apiServerURL string = "https://api.nomics.com" + apiServerPath
+	// API path on the server.
+	apiServerPath string = "/v1"
 
 	// Currencies.
 
@@ -88,9 +93,63 @@ const (
 	demoAPIKey = "demo-6410726746980cead2a17c9db9ef29af"
 )
 
+// Endpoint names, used to identify the API endpoints in caching, are the endpoint paths relative to the API server.
+const (
+	EndpointCurrenciesTicker             string = "currencies/ticker"
+	EndpointCurrenciesMetadata           string = "currencies"
+	EndpointCurrenciesSparkline          string = "currencies/sparkline"
+	EndpointCurrenciesSupplyHistory      string = "supplies/history"
+	EndpointMarkets                      string = "markets"
+	EndpointMarketsCapHistory            string = "market-cap/history"
+	EndpointExchangeMarketsTicker        string = "exchange-markets/ticker"
+	EndpointVolumeHistory                string = "volume/history"
+	EndpointExchangeRates                string = "exchange-rates"
+	EndpointExchangeRatesHistory         string = "exchange-rates/history"
+	EndpointGlobalTicker                 string = "global-ticker"
+	EndpointExchangesTicker              string = "exchanges/ticker"
+	EndpointExchangesVolumeHistory       string = "exchanges/volume/history"
+	EndpointExchangesMetadata            string = "exchanges"
+	EndpointCandles                      string = "candles"
+	EndpointExchangeCandles              string = "exchange_candles"
+	EndpointMarketsCandles               string = "markets/candles"
+	EndpointTrades                       string = "trades"
+	EndpointOrdersSnapshot               string = "orders/snapshot"
+	EndpointCurrenciesPredictionsTicker  string = "currencies/predictions/ticker"
+	EndpointCurrenciesPredictionsHistory string = "currencies/predictions/history"
+)
+
+// APIError represents a user or server error response, with a status code other than 200.
+type APIError struct {
+	Endpoint   string
+	StatusCode int
+	Status     string
+}
+
+// Error returns the status code and status of the error response.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("User or Server error. Please check. Status Code : %v, Status : %v", e.StatusCode, e.Status)
+}
+
 // Connecter to connect nomics server.
 type Connecter struct {
-	apiKey string
+	apiKey    string
+	keyHeader string
+	plan      string
+	ctx       context.Context
+
+	capabilities *CapabilityRegistry
+	cache        *responseCache
+	diskCache    *DiskCache
+	coalescer    *coalescer
+	idsChunkSize int
+	middlewares  []Middleware
+	logger       Logger
+	logLevel     LogLevel
+	metrics      *Metrics
+	usage        *UsageTracker
+	keyPool      *KeyPool
+	breaker      *CircuitBreaker
+	limiter      *rateLimiter
 
 	HTTPClient *http.Client
 }
@@ -105,30 +164,165 @@ func New(apiKey string) *Connecter {
 	return connector
 }
 
+// SetKeyHeader makes the Connecter send the API key in the header, like "X-Api-Key", instead of the key query parameter,
+// so that the key is not in the request urls. Empty name restores the key query parameter.
+func (c *Connecter) SetKeyHeader(name string) {
+	c.keyHeader = name
+}
+
+// WithContext returns a shallow copy of the Connecter whose requests are made with the ctx,
+// so that they can be cancelled. The copy shares the caches and in-flight requests with the original Connecter.
+func (c *Connecter) WithContext(ctx context.Context) *Connecter {
+	cc := *c
+	cc.ctx = ctx
+	return &cc
+}
+
 // Helper methods
 
 // newRequest creates net.http request.
 func (c *Connecter) newRequest(url string) (*http.Request, error) {
-	req, err := http.NewRequest("GET", url, nil)
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 	return req, nil
 }
 
-// do makes the net.http request to the server.
-func (c *Connecter) do(req *http.Request) (*http.Response, error) {
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
+// do checks the plan, then makes the net.http request to the server, through the logger and the middleware chain.
+// params is the request parameters struct, like CurrenciesTickerRequest.
+func (c *Connecter) do(req *http.Request, params interface{}) (*http.Response, error) {
+	if err := c.checkPlan(endpoint(req), params); err != nil {
 		return nil, err
 	}
 
+	var d Doer = DoerFunc(c.doCall)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		d = c.middlewares[i](d)
+	}
+	if c.logger != nil {
+		d = c.logCall(d)
+	}
+	return d.Do(&Call{Endpoint: endpoint(req), Params: params, Request: req})
+}
+
+// doCall makes the net.http request of the call.
+// Responses are served from and stored to the cache and the disk cache, if they are enabled,
+// and identical in-flight requests share one server call, if coalescing is enabled.
+func (c *Connecter) doCall(call *Call) (*http.Response, error) {
+	req := call.Request
+	if resp, ok := c.cache.get(req); ok {
+		c.metrics.cacheHit(call.Endpoint, "memory")
+		return resp, nil
+	}
+	if resp, ok := c.diskCache.get(req); ok {
+		c.metrics.cacheHit(call.Endpoint, "disk")
+		return resp, nil
+	}
+	if c.coalescer != nil {
+		return c.coalescer.do(req, c.fetch)
+	}
+	return c.fetch(req)
+}
+
+// fetch makes the net.http request to the server, through the circuit breaker, counted by the usage tracker
+// and with the key pool keys, and stores the response to the caches.
+func (c *Connecter) fetch(req *http.Request) (*http.Response, error) {
+	if c.breaker != nil {
+		if err := c.breaker.allow(endpoint(req)); err != nil {
+			if c.breaker.ServeStale {
+				if resp, ok := c.cache.getStale(req); ok {
+					return resp, nil
+				}
+			}
+			return nil, err
+		}
+	}
+	if c.usage != nil {
+		if err := c.usage.record(endpoint(req)); err != nil {
+			if c.breaker != nil {
+				c.breaker.release(endpoint(req))
+			}
+			return nil, err
+		}
+	}
+
+	var resp *http.Response
+	var err error
+	if c.keyPool != nil {
+		resp, err = c.keyPool.do(req, c.send, c.failover)
+	} else {
+		resp, err = c.send(req, c.apiKey)
+	}
+	if c.breaker != nil {
+		c.breaker.done(endpoint(req), resp, err)
+	}
+	if err != nil {
+		return nil, c.redactError(err)
+	}
+
 	// Check for user or server error
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("User or Server error. Please check. Status Code : %v, Status : %v", resp.StatusCode, resp.Status)
+		resp.Body.Close()
+		return nil, &APIError{Endpoint: endpoint(req), StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	resp, err = c.diskCache.put(req, resp)
+	if err != nil {
+		return nil, err
 	}
+	return c.cache.put(req, resp)
+}
+
+// send makes the net.http request to the server with the API key, after waiting for the rate limit,
+// recorded in the metrics.
+func (c *Connecter) send(req *http.Request, key string) (*http.Response, error) {
+	if c.limiter != nil {
+		waited, err := c.limiter.wait(req.Context())
+		c.metrics.wait(endpoint(req), waited)
+		if err != nil {
+			return nil, err
+		}
+	}
+	start := time.Now()
+	resp, err := c.HTTPClient.Do(c.authorize(req, key))
+	c.instrument(req, resp, err, start)
+	return resp, err
+}
+
+// failover logs the retry of the request with another key of the key pool, after the failed status,
+// and counts it in the metrics and the usage tracker.
+func (c *Connecter) failover(req *http.Request, status int) error {
+	c.log(LogWarn, "request retried", LogFields{"endpoint": endpoint(req), "status": status})
+	c.metrics.retry(endpoint(req))
+	if c.usage != nil {
+		return c.usage.record(endpoint(req))
+	}
+	return nil
+}
+
+// authorize returns a copy of the request carrying the API key, in the key header if it is set,
+// otherwise in the key query parameter.
+// It is the only place where the key is added to the requests.
+func (c *Connecter) authorize(req *http.Request, key string) *http.Request {
+	r := req.Clone(req.Context())
+	if c.keyHeader != "" {
+		r.Header.Set(c.keyHeader, key)
+		return r
+	}
+	q := r.URL.Query()
+	q.Set("key", key)
+	r.URL.RawQuery = q.Encode()
+	return r
+}
 
-	return resp, nil
+// endpoint returns the endpoint name of the request, like "currencies/ticker".
+func endpoint(req *http.Request) string {
+	return strings.TrimPrefix(req.URL.Path, apiServerPath+"/")
 }
 
 // createFile copies http response body to a new csv file on disk.
@@ -0,0 +1,89 @@
+package gonomics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc is used as http client Transport to test the connector without hitting the nomics server.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newTestResponse creates a http response with the status code and body.
+func newTestResponse(req *http.Request, statusCode int, body string) *http.Response {
+	return &http.Response{
+		Status:     http.StatusText(statusCode),
+		StatusCode: statusCode,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+// TestEndpoint tests endpoint name of the API endpoint URLs.
+func TestEndpoint(t *testing.T) {
+	urls := map[string]string{
+		currenciesTickerURL:             EndpointCurrenciesTicker,
+		currenciesMetadataURL:           EndpointCurrenciesMetadata,
+		currenciesSparklineURL:          EndpointCurrenciesSparkline,
+		currenciesSupplyHistoryURL:      EndpointCurrenciesSupplyHistory,
+		marketsURL:                      EndpointMarkets,
+		marketsCapHistoryURL:            EndpointMarketsCapHistory,
+		exchangeMarketsTickerURL:        EndpointExchangeMarketsTicker,
+		volumeHistoryURL:                EndpointVolumeHistory,
+		exchangeRatesURL:                EndpointExchangeRates,
+		exchangeRatesHistoryURL:         EndpointExchangeRatesHistory,
+		globalTickerURL:                 EndpointGlobalTicker,
+		exchangesTickerURL:              EndpointExchangesTicker,
+		exchangesVolumeHistoryURL:       EndpointExchangesVolumeHistory,
+		exchangesMetadataURL:            EndpointExchangesMetadata,
+		candlesURL:                      EndpointCandles,
+		exchangeCandlesURL:              EndpointExchangeCandles,
+		marketsCandlesURL:               EndpointMarketsCandles,
+		tradesURL:                       EndpointTrades,
+		ordersSnapshotURL:               EndpointOrdersSnapshot,
+		currenciesPredictionsTickerURL:  EndpointCurrenciesPredictionsTicker,
+		currenciesPredictionsHistoryURL: EndpointCurrenciesPredictionsHistory,
+	}
+	c := New(demoAPIKey)
+	for url, expected := range urls {
+		req, err := c.newRequest(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := endpoint(req); e != expected {
+			t.Errorf("Something is wrong here, expected endpoint %v for %v, got %v.", expected, url, e)
+		}
+	}
+}
+
+// TestKeyHeader tests sending the API key in the key query parameter and in a header.
+func TestKeyHeader(t *testing.T) {
+	var query, header string
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		query, header = req.URL.Query().Get("key"), req.Header.Get("X-Api-Key")
+		return newTestResponse(req, http.StatusOK, `[]`), nil
+	})
+
+	if _, err := c.GetMarkets(MarketsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if query != demoAPIKey || header != "" {
+		t.Errorf("Something is wrong here, expected key query parameter, got query %v and header %v.", query, header)
+	}
+
+	c.SetKeyHeader("X-Api-Key")
+	if _, err := c.GetMarkets(MarketsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if query != "" || header != demoAPIKey {
+		t.Errorf("Something is wrong here, expected key header, got query %v and header %v.", query, header)
+	}
+}
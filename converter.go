@@ -0,0 +1,240 @@
+package gonomics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Currency Converter.
+
+// usdCurrency is the base currency of the nomics exchange rates.
+const usdCurrency = "USD"
+
+// Converter converts amounts between any two currencies, crypto or fiat, via USD using the nomics exchange rates.
+// Rates table and rates history are cached, so that repeated conversions do not hit the server every time.
+type Converter struct {
+	c *Connecter
+
+	// TTL is how long the cached rates table is used before it is fetched again from the server.
+	// Default 0 means the rates table is fetched only once, until Refresh is called.
+	TTL time.Duration
+
+	// MaxStaleness is the maximum allowed distance between the requested time and the nearest
+	// rates history point used in ConvertAt. Default 0 means 24 hours.
+	MaxStaleness time.Duration
+
+	mu        sync.Mutex
+	rates     map[string]ExchangeRatesResponse
+	fetchedAt time.Time
+	history   map[string][]ExchangeRatesHistoryResponse
+}
+
+// Conversion represents the result of a currency conversion along with the rates used for it.
+// Rates are USD based, so the amount is converted as Amount * FromRate.Rate / ToRate.Rate.
+type Conversion struct {
+	Amount   float64
+	From     string
+	To       string
+	Result   float64
+	FromRate ExchangeRatesResponse
+	ToRate   ExchangeRatesResponse
+}
+
+// NewConverter creates a brand new Converter using the Connecter to fetch exchange rates.
+// Modify Converter TTL and MaxStaleness to specific needs once this function returns.
+func (c *Connecter) NewConverter() *Converter {
+	return &Converter{
+		c:       c,
+		history: make(map[string][]ExchangeRatesHistoryResponse),
+	}
+}
+
+// Refresh fetches the rates table from the server and replaces the cached one.
+func (cv *Converter) Refresh() error {
+	erResp, err := cv.c.GetExchangeRates(ExchangeRatesRequest{})
+	if err != nil {
+		return err
+	}
+	cv.LoadRates(erResp)
+	return nil
+}
+
+// LoadRates replaces the cached rates table with the given exchange rates, without hitting the server.
+func (cv *Converter) LoadRates(erResp []ExchangeRatesResponse) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	cv.rates = make(map[string]ExchangeRatesResponse, len(erResp))
+	for _, er := range erResp {
+		cv.rates[strings.ToUpper(er.Currency)] = er
+	}
+	cv.fetchedAt = time.Now()
+}
+
+// LoadRatesHistory adds the given exchange rates history points of the currency to the cache, without hitting the server.
+func (cv *Converter) LoadRatesHistory(currency string, erhResp []ExchangeRatesHistoryResponse) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	cv.mergeHistory(strings.ToUpper(currency), erhResp)
+}
+
+// Convert converts the amount from one currency to another using the latest rates table.
+func (cv *Converter) Convert(amount float64, from, to string) (Conversion, error) {
+	if err := cv.ensureRates(); err != nil {
+		return Conversion{}, err
+	}
+
+	cv.mu.Lock()
+	fromRate, fromErr := cv.rate(from)
+	toRate, toErr := cv.rate(to)
+	cv.mu.Unlock()
+	if fromErr != nil {
+		return Conversion{}, fromErr
+	}
+	if toErr != nil {
+		return Conversion{}, toErr
+	}
+	return newConversion(amount, from, to, fromRate, toRate)
+}
+
+// ConvertAt converts the amount from one currency to another at the given time,
+// using the nearest rates history point of each currency.
+// It returns an error if the nearest point is further than MaxStaleness from the given time.
+func (cv *Converter) ConvertAt(amount float64, from, to string, at time.Time) (Conversion, error) {
+	fromRate, err := cv.rateAt(from, at)
+	if err != nil {
+		return Conversion{}, err
+	}
+	toRate, err := cv.rateAt(to, at)
+	if err != nil {
+		return Conversion{}, err
+	}
+	return newConversion(amount, from, to, fromRate, toRate)
+}
+
+// Helper methods
+
+// ensureRates fetches the rates table if it is not cached yet or the cached one is older than TTL.
+func (cv *Converter) ensureRates() error {
+	cv.mu.Lock()
+	expired := cv.rates == nil || (cv.TTL > 0 && time.Since(cv.fetchedAt) > cv.TTL)
+	cv.mu.Unlock()
+	if expired {
+		return cv.Refresh()
+	}
+	return nil
+}
+
+// rate returns the cached USD rate of the currency. Lock must be held by the caller.
+func (cv *Converter) rate(currency string) (ExchangeRatesResponse, error) {
+	currency = strings.ToUpper(currency)
+	if er, ok := cv.rates[currency]; ok {
+		return er, nil
+	}
+	if currency == usdCurrency {
+		return ExchangeRatesResponse{Currency: usdCurrency, Rate: 1, Timestamp: cv.fetchedAt}, nil
+	}
+	return ExchangeRatesResponse{}, fmt.Errorf("exchange rate not found for currency %v", currency)
+}
+
+// rateAt returns the USD rate of the currency nearest to the given time,
+// fetching the rates history around the time from the server if the cached one is too stale.
+func (cv *Converter) rateAt(currency string, at time.Time) (ExchangeRatesResponse, error) {
+	currency = strings.ToUpper(currency)
+	if currency == usdCurrency {
+		return ExchangeRatesResponse{Currency: usdCurrency, Rate: 1, Timestamp: at}, nil
+	}
+	maxStaleness := cv.MaxStaleness
+	if maxStaleness == 0 {
+		maxStaleness = 24 * time.Hour
+	}
+
+	cv.mu.Lock()
+	erh, ok := nearestRate(cv.history[currency], at, maxStaleness)
+	cv.mu.Unlock()
+	if ok {
+		return ExchangeRatesResponse{Currency: currency, Rate: erh.Rate, Timestamp: erh.Timestamp}, nil
+	}
+
+	erhResp, err := cv.c.GetExchangeRatesHistory(ExchangeRatesHistoryRequest{
+		Currency: currency,
+		Start:    at.Add(-maxStaleness),
+		End:      at.Add(maxStaleness),
+	})
+	if err != nil {
+		return ExchangeRatesResponse{}, err
+	}
+
+	cv.mu.Lock()
+	cv.mergeHistory(currency, erhResp)
+	erh, ok = nearestRate(cv.history[currency], at, maxStaleness)
+	cv.mu.Unlock()
+	if !ok {
+		return ExchangeRatesResponse{}, fmt.Errorf("no exchange rate for currency %v within %v of %v", currency, maxStaleness, at.Format(time.RFC3339))
+	}
+	return ExchangeRatesResponse{Currency: currency, Rate: erh.Rate, Timestamp: erh.Timestamp}, nil
+}
+
+// mergeHistory merges the rates history points into the cached, timestamp sorted, history of the currency.
+// Lock must be held by the caller.
+func (cv *Converter) mergeHistory(currency string, erhResp []ExchangeRatesHistoryResponse) {
+	if cv.history == nil {
+		cv.history = make(map[string][]ExchangeRatesHistoryResponse)
+	}
+	merged := append(cv.history[currency], erhResp...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Timestamp.Before(merged[j].Timestamp)
+	})
+	dedup := merged[:0]
+	for i, erh := range merged {
+		if i > 0 && erh.Timestamp.Equal(dedup[len(dedup)-1].Timestamp) {
+			dedup[len(dedup)-1] = erh
+			continue
+		}
+		dedup = append(dedup, erh)
+	}
+	cv.history[currency] = dedup
+}
+
+// nearestRate returns the history point nearest to the given time, if it is within maxStaleness.
+func nearestRate(history []ExchangeRatesHistoryResponse, at time.Time, maxStaleness time.Duration) (ExchangeRatesHistoryResponse, bool) {
+	i := sort.Search(len(history), func(i int) bool {
+		return !history[i].Timestamp.Before(at)
+	})
+	best := -1
+	var bestDist time.Duration
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= len(history) {
+			continue
+		}
+		dist := history[j].Timestamp.Sub(at)
+		if dist < 0 {
+			dist = -dist
+		}
+		if best == -1 || dist < bestDist {
+			best, bestDist = j, dist
+		}
+	}
+	if best == -1 || bestDist > maxStaleness {
+		return ExchangeRatesHistoryResponse{}, false
+	}
+	return history[best], true
+}
+
+// newConversion converts the amount using USD based rates of both currencies.
+func newConversion(amount float64, from, to string, fromRate, toRate ExchangeRatesResponse) (Conversion, error) {
+	if toRate.Rate == 0 {
+		return Conversion{}, errors.New("exchange rate of target currency is 0")
+	}
+	return Conversion{
+		Amount:   amount,
+		From:     strings.ToUpper(from),
+		To:       strings.ToUpper(to),
+		Result:   amount * fromRate.Rate / toRate.Rate,
+		FromRate: fromRate,
+		ToRate:   toRate,
+	}, nil
+}
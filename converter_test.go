@@ -0,0 +1,51 @@
+package gonomics
+
+import (
+	"testing"
+	"time"
+)
+
+// TestConverter tests currency conversion functions using cached rates.
+func TestConverter(t *testing.T) {
+	cv := New(demoAPIKey).NewConverter()
+	now := time.Now()
+	cv.LoadRates([]ExchangeRatesResponse{
+		{Currency: "BTC", Rate: 50000, Timestamp: now},
+		{Currency: "EUR", Rate: 1.25, Timestamp: now},
+	})
+
+	conv, err := cv.Convert(2, "btc", "EUR")
+	if err != nil {
+		t.Error(err)
+	}
+	if conv.Result != 80000 || conv.FromRate.Currency != "BTC" || conv.ToRate.Currency != "EUR" {
+		t.Errorf("Something is wrong here, unexpected conversion %v.", conv)
+	}
+	conv, err = cv.Convert(100, "USD", "EUR")
+	if err != nil {
+		t.Error(err)
+	}
+	if conv.Result != 80 {
+		t.Errorf("Something is wrong here, expected 80 EUR, got %v.", conv.Result)
+	}
+	if _, err := cv.Convert(1, "XYZ", "EUR"); err == nil {
+		t.Error("Something is wrong here, conversion of unknown currency should fail.")
+	}
+
+	// Historical conversion.
+	startTime, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+	cv.LoadRatesHistory("BTC", []ExchangeRatesHistoryResponse{
+		{Timestamp: startTime, Rate: 30000},
+		{Timestamp: startTime.Add(24 * time.Hour), Rate: 32000},
+	})
+	cv.LoadRatesHistory("EUR", []ExchangeRatesHistoryResponse{
+		{Timestamp: startTime, Rate: 1.2},
+	})
+	conv, err = cv.ConvertAt(1, "BTC", "EUR", startTime.Add(20*time.Hour))
+	if err != nil {
+		t.Error(err)
+	}
+	if conv.FromRate.Rate != 32000 || conv.ToRate.Rate != 1.2 {
+		t.Errorf("Something is wrong here, nearest rates are not used %v.", conv)
+	}
+}
@@ -85,6 +85,24 @@ type CurrenciesTickerIntervalVolumeTransparencyResponse struct {
 
 // GetCurrenciesTicker fetches the currency ticker from the server and returns array of CurrenciesTickerResponse.
 func (c *Connecter) GetCurrenciesTicker(ctReq CurrenciesTickerRequest) ([]CurrenciesTickerResponse, error) {
+	// Split long ids into sub-requests, merged in the ids order.
+	var merged []CurrenciesTickerResponse
+	chunked, err := c.getChunked(ctReq.Ids, ctReq.Page, ctReq.PerPage, "", func(ids []string) error {
+		chunkReq := ctReq
+		chunkReq.Ids = ids
+		resp, err := c.GetCurrenciesTicker(chunkReq)
+		merged = append(merged, resp...)
+		return err
+	}, func() {
+		sortByIds(merged, ctReq.Ids, func(i int) string { return merged[i].ID })
+	})
+	if err != nil {
+		return nil, err
+	}
+	if chunked {
+		return merged, nil
+	}
+
 	req, err := c.newRequest(currenciesTickerURL)
 	if err != nil {
 		return nil, err
@@ -92,7 +110,6 @@ func (c *Connecter) GetCurrenciesTicker(ctReq CurrenciesTickerRequest) ([]Curren
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if len(ctReq.Ids) > 0 {
 		q.Add("ids", strings.Join(ctReq.Ids[:], ","))
 	}
@@ -123,7 +140,7 @@ func (c *Connecter) GetCurrenciesTicker(ctReq CurrenciesTickerRequest) ([]Curren
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, ctReq)
 	if err != nil {
 		return nil, err
 	}
@@ -188,6 +205,24 @@ type CurrenciesMetadataResponse struct {
 // Note : in case of csv format, CurrenciesMetadataRequest.FileNameWithPath is required
 // and the []CurrenciesMetadataResponse return data is nil.
 func (c *Connecter) GetCurrenciesMetadata(cmReq CurrenciesMetadataRequest) ([]CurrenciesMetadataResponse, error) {
+	// Split long ids into sub-requests, merged in the ids order.
+	var merged []CurrenciesMetadataResponse
+	chunked, err := c.getChunked(cmReq.Ids, 0, 0, cmReq.Format, func(ids []string) error {
+		chunkReq := cmReq
+		chunkReq.Ids = ids
+		resp, err := c.GetCurrenciesMetadata(chunkReq)
+		merged = append(merged, resp...)
+		return err
+	}, func() {
+		sortByIds(merged, cmReq.Ids, func(i int) string { return merged[i].ID })
+	})
+	if err != nil {
+		return nil, err
+	}
+	if chunked {
+		return merged, nil
+	}
+
 	req, err := c.newRequest(currenciesMetadataURL)
 	if err != nil {
 		return nil, err
@@ -195,7 +230,6 @@ func (c *Connecter) GetCurrenciesMetadata(cmReq CurrenciesMetadataRequest) ([]Cu
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if len(cmReq.Ids) > 0 {
 		q.Add("ids", strings.Join(cmReq.Ids[:], ","))
 	}
@@ -211,7 +245,7 @@ func (c *Connecter) GetCurrenciesMetadata(cmReq CurrenciesMetadataRequest) ([]Cu
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, cmReq)
 	if err != nil {
 		return nil, err
 	}
@@ -274,6 +308,24 @@ func (cs *CurrenciesSparklineResponse) UnmarshalJSON(data []byte) error {
 
 // GetCurrenciesSparkline fetches the currency sparklines from the server and returns array of CurrenciesSparklineResponse.
 func (c *Connecter) GetCurrenciesSparkline(csReq CurrenciesSparklineRequest) ([]CurrenciesSparklineResponse, error) {
+	// Split long ids into sub-requests, merged in the ids order.
+	var merged []CurrenciesSparklineResponse
+	chunked, err := c.getChunked(csReq.Ids, 0, 0, "", func(ids []string) error {
+		chunkReq := csReq
+		chunkReq.Ids = ids
+		resp, err := c.GetCurrenciesSparkline(chunkReq)
+		merged = append(merged, resp...)
+		return err
+	}, func() {
+		sortByIds(merged, csReq.Ids, func(i int) string { return merged[i].Currency })
+	})
+	if err != nil {
+		return nil, err
+	}
+	if chunked {
+		return merged, nil
+	}
+
 	req, err := c.newRequest(currenciesSparklineURL)
 	if err != nil {
 		return nil, err
@@ -281,7 +333,6 @@ func (c *Connecter) GetCurrenciesSparkline(csReq CurrenciesSparklineRequest) ([]
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if len(csReq.Ids) > 0 {
 		q.Add("ids", strings.Join(csReq.Ids[:], ","))
 	}
@@ -298,7 +349,7 @@ func (c *Connecter) GetCurrenciesSparkline(csReq CurrenciesSparklineRequest) ([]
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, csReq)
 	if err != nil {
 		return nil, err
 	}
@@ -351,7 +402,6 @@ func (c *Connecter) GetCurrenciesSupplyHistory(cshReq CurrenciesSupplyHistoryReq
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if cshReq.Currency == "" {
 		return nil, errors.New("currency is required")
 	}
@@ -372,7 +422,7 @@ func (c *Connecter) GetCurrenciesSupplyHistory(cshReq CurrenciesSupplyHistoryReq
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, cshReq)
 	if err != nil {
 		return nil, err
 	}
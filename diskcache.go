@@ -0,0 +1,322 @@
+package gonomics
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Disk Cache.
+
+// historicalEndpoints are the endpoints whose responses are immutable once the requested end is settled in the past.
+var historicalEndpoints = map[string]bool{
+	EndpointCandles:                 true,
+	EndpointExchangeCandles:         true,
+	EndpointMarketsCandles:          true,
+	EndpointExchangeRatesHistory:    true,
+	EndpointMarketsCapHistory:       true,
+	EndpointVolumeHistory:           true,
+	EndpointExchangesVolumeHistory:  true,
+	EndpointCurrenciesSupplyHistory: true,
+}
+
+const (
+	// diskCacheExt is the file extension of the disk cache entries.
+	diskCacheExt = ".cache"
+	// diskCacheTempPrefix is the file name prefix of the entries being written.
+	diskCacheTempPrefix = "tmp-"
+	// diskCacheTempMaxAge is the age after which an entry being written is considered left behind by a crash.
+	diskCacheTempMaxAge = time.Hour
+	// defaultDiskCacheSettle is the default time after the end of a request before its response is immutable.
+	defaultDiskCacheSettle = time.Hour
+)
+
+// DiskCache is a persistent on-disk cache of the immutable historical responses, like candles or
+// exchange-rates history with the End in the past. The End must be older than the settle margin, and than one
+// interval of candles requests, so that the last candles and data points still being updated are not stored.
+// Entries are stored indefinitely in the cache directory, and survive process restarts.
+// Least recently used entries are removed once the size limit is reached.
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+	settle   time.Duration
+	mu       sync.Mutex
+}
+
+// diskCacheMeta is the metadata line stored at the beginning of each disk cache entry.
+type diskCacheMeta struct {
+	Key      string      `json:"key"`
+	Checksum string      `json:"checksum"`
+	Header   http.Header `json:"header"`
+	StoredAt time.Time   `json:"stored_at"`
+}
+
+// NewDiskCache creates a brand new DiskCache in the directory, creating the directory if needed,
+// and removes the temporary files older than an hour, left behind by a crash or a failed write.
+// maxBytes limits the total size of the cache entries, 0 means no limit.
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if dir == "" {
+		return nil, errors.New("cache directory is required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	dc := &DiskCache{dir: dir, maxBytes: maxBytes, settle: defaultDiskCacheSettle}
+	if err := dc.sweep(time.Now().Add(-diskCacheTempMaxAge)); err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+// SetDiskCache sets the DiskCache used to store the immutable historical responses. Set nil to disable it.
+func (c *Connecter) SetDiskCache(dc *DiskCache) {
+	c.diskCache = dc
+}
+
+// SetSettleMargin sets how long after the End of a request its response is immutable and stored, default one hour.
+func (dc *DiskCache) SetSettleMargin(d time.Duration) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.settle = d
+}
+
+// Size returns the total size of the cache entries in bytes.
+func (dc *DiskCache) Size() (int64, error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	files, err := dc.entries()
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, fi := range files {
+		size += fi.Size()
+	}
+	return size, nil
+}
+
+// Purge removes all the cache entries.
+func (dc *DiskCache) Purge() error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	files, err := dc.entries()
+	if err != nil {
+		return err
+	}
+	for _, fi := range files {
+		if err := os.Remove(filepath.Join(dc.dir, fi.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify checks the checksum of every cache entry, removes the corrupted ones
+// and returns the cache keys of the removed entries.
+func (dc *DiskCache) Verify() ([]string, error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	files, err := dc.entries()
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, fi := range files {
+		name := filepath.Join(dc.dir, fi.Name())
+		meta, _, err := readDiskCacheEntry(name)
+		if err == nil {
+			continue
+		}
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		if meta.Key == "" {
+			meta.Key = fi.Name()
+		}
+		removed = append(removed, meta.Key)
+	}
+	return removed, nil
+}
+
+// Helper methods
+
+// immutable returns true if the request is for a historical endpoint with end older than the settle margin,
+// and than one interval of the request, if any.
+func (dc *DiskCache) immutable(req *http.Request) bool {
+	if !historicalEndpoints[endpoint(req)] {
+		return false
+	}
+	q := req.URL.Query()
+	end, err := time.Parse(time.RFC3339, q.Get("end"))
+	if err != nil {
+		return false
+	}
+	dc.mu.Lock()
+	settle := dc.settle
+	dc.mu.Unlock()
+	if interval, err := intervalDuration(q.Get("interval")); err == nil && interval > settle {
+		settle = interval
+	}
+	return end.Add(settle).Before(time.Now())
+}
+
+// get returns the cached response of the request, if the request is immutable and cached.
+func (dc *DiskCache) get(req *http.Request) (*http.Response, bool) {
+	if dc == nil || !dc.immutable(req) {
+		return nil, false
+	}
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	key := cacheKey(req)
+	name := dc.path(key)
+	meta, body, err := readDiskCacheEntry(name)
+	if err != nil || meta.Key != key {
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(name, now, now)
+	entry := &cacheEntry{key: key, endpoint: endpoint(req), header: meta.Header, body: body}
+	return entry.response(req), true
+}
+
+// put stores the response of the request, if the request is immutable, and returns the response with a re-readable body.
+// Failure to write the cache entry is not an error, the response is returned as is.
+func (dc *DiskCache) put(req *http.Request, resp *http.Response) (*http.Response, error) {
+	if dc == nil || !dc.immutable(req) {
+		return resp, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	key := cacheKey(req)
+	sum := sha256.Sum256(body)
+	meta, err := json.Marshal(diskCacheMeta{
+		Key:      key,
+		Checksum: hex.EncodeToString(sum[:]),
+		Header:   resp.Header,
+		StoredAt: time.Now(),
+	})
+	if err != nil {
+		return resp, nil
+	}
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	tmp, err := ioutil.TempFile(dc.dir, diskCacheTempPrefix)
+	if err != nil {
+		return resp, nil
+	}
+	_, err = tmp.Write(append(append(meta, '\n'), body...))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), dc.path(key)) != nil {
+		os.Remove(tmp.Name())
+		return resp, nil
+	}
+	dc.evict()
+	return resp, nil
+}
+
+// evict removes the least recently used entries until the cache is within its size limit.
+// Lock must be held by the caller.
+func (dc *DiskCache) evict() {
+	if dc.maxBytes <= 0 {
+		return
+	}
+	files, err := dc.entries()
+	if err != nil {
+		return
+	}
+	var size int64
+	for _, fi := range files {
+		size += fi.Size()
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, fi := range files {
+		if size <= dc.maxBytes {
+			return
+		}
+		if os.Remove(filepath.Join(dc.dir, fi.Name())) == nil {
+			size -= fi.Size()
+		}
+	}
+}
+
+// entries returns the file info of all the cache entries. Lock must be held by the caller.
+func (dc *DiskCache) entries() ([]os.FileInfo, error) {
+	files, err := ioutil.ReadDir(dc.dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := files[:0]
+	for _, fi := range files {
+		if !fi.IsDir() && strings.HasSuffix(fi.Name(), diskCacheExt) {
+			entries = append(entries, fi)
+		}
+	}
+	return entries, nil
+}
+
+// sweep removes the temporary files modified before the time.
+func (dc *DiskCache) sweep(before time.Time) error {
+	files, err := ioutil.ReadDir(dc.dir)
+	if err != nil {
+		return err
+	}
+	for _, fi := range files {
+		if fi.IsDir() || !strings.HasPrefix(fi.Name(), diskCacheTempPrefix) || !fi.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dc.dir, fi.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// path returns the file path of the cache entry of the key.
+func (dc *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dc.dir, hex.EncodeToString(sum[:])+diskCacheExt)
+}
+
+// readDiskCacheEntry reads the cache entry file and verifies the checksum of its body.
+func readDiskCacheEntry(name string) (diskCacheMeta, []byte, error) {
+	var meta diskCacheMeta
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return meta, nil, err
+	}
+	line, err := bufio.NewReader(bytes.NewReader(data)).ReadBytes('\n')
+	if err != nil {
+		return meta, nil, errors.New("cache entry metadata is missing")
+	}
+	if err := json.Unmarshal(line, &meta); err != nil {
+		return meta, nil, err
+	}
+	body := data[len(line):]
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != meta.Checksum {
+		return meta, nil, errors.New("cache entry checksum mismatch")
+	}
+	return meta, body, nil
+}
@@ -0,0 +1,135 @@
+package gonomics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestDiskCache tests persistent on-disk caching of historical responses.
+func TestDiskCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonomics_disk_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	calls := 0
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return newTestResponse(req, http.StatusOK, `[{"timestamp":"2021-01-01T00:00:00Z","rate":"30000"}]`), nil
+	})
+	newConnecter := func() *Connecter {
+		c := New(demoAPIKey)
+		c.HTTPClient.Transport = transport
+		dc, err := NewDiskCache(dir, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetDiskCache(dc)
+		return c
+	}
+
+	startTime, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+	erhReq := ExchangeRatesHistoryRequest{Currency: "BTC", Start: startTime, End: startTime.Add(24 * time.Hour)}
+	for i := 0; i < 2; i++ {
+		// New connecter each time, like a process restart.
+		erhResp, err := newConnecter().GetExchangeRatesHistory(erhReq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(erhResp) != 1 || erhResp[0].Rate != 30000 {
+			t.Errorf("Something is wrong here, unexpected cached response %v.", erhResp)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Something is wrong here, expected 1 server call, got %v.", calls)
+	}
+
+	// Request with end in the future is not immutable.
+	c := newConnecter()
+	erhReq.End = time.Now().Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetExchangeRatesHistory(erhReq); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("Something is wrong here, expected 3 server calls, got %v.", calls)
+	}
+
+	// Verify removes corrupted entries.
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+diskCacheExt))
+	if len(files) != 1 {
+		t.Fatalf("Something is wrong here, expected 1 cache entry, got %v.", len(files))
+	}
+	if err := ioutil.WriteFile(files[0], []byte("{}\ncorrupted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := c.diskCache.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 {
+		t.Errorf("Something is wrong here, expected 1 removed entry, got %v.", removed)
+	}
+
+	// Request with end within the settle margin is not immutable, nor within one interval of candles.
+	erhReq.End = time.Now().Add(-30 * time.Minute)
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetExchangeRatesHistory(erhReq); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.diskCache.SetSettleMargin(time.Minute)
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetExchangeRatesHistory(erhReq); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 6 {
+		t.Errorf("Something is wrong here, expected 6 server calls, got %v.", calls)
+	}
+	req := httptest.NewRequest("GET", candlesURL+"?interval=1d&end="+time.Now().Add(-2*time.Hour).Format(time.RFC3339), nil)
+	if c.diskCache.immutable(req) {
+		t.Error("Something is wrong here, expected candles request within one interval not immutable.")
+	}
+
+	// Purge.
+	erhReq.End = startTime.Add(24 * time.Hour)
+	if _, err := c.GetExchangeRatesHistory(erhReq); err != nil {
+		t.Fatal(err)
+	}
+	if size, _ := c.diskCache.Size(); size == 0 {
+		t.Error("Something is wrong here, cache entry is not stored.")
+	}
+	if err := c.diskCache.Purge(); err != nil {
+		t.Fatal(err)
+	}
+	if size, _ := c.diskCache.Size(); size != 0 {
+		t.Errorf("Something is wrong here, cache is not purged, size %v.", size)
+	}
+
+	// Stale temporary files are removed on open, recent ones may still be written.
+	stale, recent := filepath.Join(dir, "tmp-stale"), filepath.Join(dir, "tmp-recent")
+	for _, name := range []string{stale, recent} {
+		if err := ioutil.WriteFile(name, []byte("partial"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+	newConnecter()
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Something is wrong here, expected stale temporary file removed, got %v.", err)
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("Something is wrong here, expected recent temporary file kept, got %v.", err)
+	}
+}
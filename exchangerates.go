@@ -41,7 +41,6 @@ func (c *Connecter) GetExchangeRates(erReq ExchangeRatesRequest) ([]ExchangeRate
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if erReq.Format != "" {
 		if erReq.Format == "csv" && erReq.FileNameWithPath == "" {
 			return nil, errors.New("csv file path is required")
@@ -51,7 +50,7 @@ func (c *Connecter) GetExchangeRates(erReq ExchangeRatesRequest) ([]ExchangeRate
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, erReq)
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +111,6 @@ func (c *Connecter) GetExchangeRatesHistory(erhReq ExchangeRatesHistoryRequest)
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if erhReq.Currency == "" {
 		return nil, errors.New("currency is required")
 	}
@@ -133,7 +131,7 @@ func (c *Connecter) GetExchangeRatesHistory(erhReq ExchangeRatesHistoryRequest)
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, erhReq)
 	if err != nil {
 		return nil, err
 	}
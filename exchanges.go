@@ -66,6 +66,24 @@ type ExchangesTickerIntervalResponse struct {
 
 // GetExchangesTicker fetches the exchanges ticker from the server and returns array of ExchangesTickerResponse.
 func (c *Connecter) GetExchangesTicker(etReq ExchangesTickerRequest) ([]ExchangesTickerResponse, error) {
+	// Split long ids into sub-requests, merged in the ids order.
+	var merged []ExchangesTickerResponse
+	chunked, err := c.getChunked(etReq.Ids, etReq.Page, etReq.PerPage, "", func(ids []string) error {
+		chunkReq := etReq
+		chunkReq.Ids = ids
+		resp, err := c.GetExchangesTicker(chunkReq)
+		merged = append(merged, resp...)
+		return err
+	}, func() {
+		sortByIds(merged, etReq.Ids, func(i int) string { return merged[i].ID })
+	})
+	if err != nil {
+		return nil, err
+	}
+	if chunked {
+		return merged, nil
+	}
+
 	req, err := c.newRequest(exchangesTickerURL)
 	if err != nil {
 		return nil, err
@@ -73,7 +91,6 @@ func (c *Connecter) GetExchangesTicker(etReq ExchangesTickerRequest) ([]Exchange
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if len(etReq.Ids) > 0 {
 		q.Add("ids", strings.Join(etReq.Ids[:], ","))
 	}
@@ -98,7 +115,7 @@ func (c *Connecter) GetExchangesTicker(etReq ExchangesTickerRequest) ([]Exchange
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, etReq)
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +171,6 @@ func (c *Connecter) GetExchangesVolumeHistory(evhReq ExchangesVolumeHistoryReque
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if evhReq.Exchange == "" {
 		return nil, errors.New("exchange is required")
 	}
@@ -181,7 +197,7 @@ func (c *Connecter) GetExchangesVolumeHistory(evhReq ExchangesVolumeHistoryReque
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, evhReq)
 	if err != nil {
 		return nil, err
 	}
@@ -262,7 +278,6 @@ func (c *Connecter) GetExchangesMetadata(emReq ExchangesMetadataRequest) ([]Exch
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if len(emReq.Ids) > 0 {
 		q.Add("ids", strings.Join(emReq.Ids[:], ","))
 	}
@@ -278,7 +293,7 @@ func (c *Connecter) GetExchangesMetadata(emReq ExchangesMetadataRequest) ([]Exch
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, emReq)
 	if err != nil {
 		return nil, err
 	}
@@ -65,14 +65,13 @@ func (c *Connecter) GetGlobalTicker(gtReq GlobalTickerRequest) ([]GlobalTickerRe
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if gtReq.Convert != "" {
 		q.Add("convert", gtReq.Convert)
 	}
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, gtReq)
 	if err != nil {
 		return nil, err
 	}
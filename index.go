@@ -0,0 +1,316 @@
+package gonomics
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Market-Cap Dominance.
+
+// CurrencyDominance represents the share of one currency in the total market cap.
+// Dominance pct fields are fractions (0.45 is 45%).
+type CurrencyDominance struct {
+	ID                      string
+	MarketCap               float64
+	DominancePct            float64
+	TransparentMarketCap    float64
+	TransparentDominancePct float64
+}
+
+// Dominance computes the market-cap dominance of each currency in the currencies ticker against
+// the total market cap of the global-ticker. Both must be fetched in the same Convert currency.
+func Dominance(ctResp []CurrenciesTickerResponse, gt GlobalTickerResponse) []CurrencyDominance {
+	cds := make([]CurrencyDominance, 0, len(ctResp))
+	for _, ct := range ctResp {
+		cd := CurrencyDominance{
+			ID:                   ct.ID,
+			MarketCap:            ct.MarketCap,
+			TransparentMarketCap: ct.TransparentMarketCap,
+		}
+		if gt.MarketCap != 0 {
+			cd.DominancePct = ct.MarketCap / gt.MarketCap
+		}
+		if gt.TransparentMarketCap != 0 {
+			cd.TransparentDominancePct = ct.TransparentMarketCap / gt.TransparentMarketCap
+		}
+		cds = append(cds, cd)
+	}
+	return cds
+}
+
+// GetDominance fetches the currencies ticker and the global-ticker from the server
+// and returns the market-cap dominance of each currency.
+func (c *Connecter) GetDominance(ctReq CurrenciesTickerRequest) ([]CurrencyDominance, error) {
+	gtResp, err := c.GetGlobalTicker(GlobalTickerRequest{Convert: ctReq.Convert})
+	if err != nil {
+		return nil, err
+	}
+	if len(gtResp) < 1 {
+		return nil, errors.New("global ticker is empty")
+	}
+	ctResp, err := c.GetCurrenciesTicker(ctReq)
+	if err != nil {
+		return nil, err
+	}
+	return Dominance(ctResp, gtResp[0]), nil
+}
+
+// Index.
+
+// Index weighting methods.
+const (
+	// IndexMarketCapWeighted weights the constituents by their market cap.
+	IndexMarketCapWeighted string = "market-cap"
+	// IndexEqualWeighted weights all the constituents equally.
+	IndexEqualWeighted string = "equal"
+)
+
+// IndexDefinition represents a custom index of the top currencies by market cap.
+type IndexDefinition struct {
+	Name string
+
+	// Number of the top currencies by market cap included in the index.
+	TopN int
+
+	// IndexMarketCapWeighted or IndexEqualWeighted. Default is IndexMarketCapWeighted.
+	Weighting string
+
+	// Maximum weight of a single constituent as a fraction, like 0.25.
+	// Excess weight is redistributed to the other constituents. Default 0 means no cap.
+	MaxWeight float64
+
+	// Period of rebalancing the constituents back to the target weights.
+	// Default 0 means the index is never rebalanced.
+	Rebalance time.Duration
+
+	// Level of the index at the first timestamp. Default is 1000.
+	BaseLevel float64
+
+	// Currency ids never included in the index, like stablecoins.
+	Exclude []string
+}
+
+// IndexConstituent represents one currency of the index and its weight as a fraction.
+type IndexConstituent struct {
+	ID        string
+	MarketCap float64
+	Weight    float64
+}
+
+// IndexLevel represents the level of the index at a time, along with the constituent weights at that time.
+type IndexLevel struct {
+	Timestamp    time.Time
+	Level        float64
+	Rebalanced   bool
+	Constituents []IndexConstituent
+}
+
+// Constituents selects the top currencies by market cap from the currencies ticker and returns them with their target weights.
+func (d IndexDefinition) Constituents(ctResp []CurrenciesTickerResponse) []IndexConstituent {
+	exclude := make(map[string]bool, len(d.Exclude))
+	for _, id := range d.Exclude {
+		exclude[strings.ToUpper(id)] = true
+	}
+	var ics []IndexConstituent
+	for _, ct := range ctResp {
+		if exclude[strings.ToUpper(ct.ID)] || ct.MarketCap <= 0 {
+			continue
+		}
+		ics = append(ics, IndexConstituent{ID: strings.ToUpper(ct.ID), MarketCap: ct.MarketCap})
+	}
+	sort.SliceStable(ics, func(i, j int) bool {
+		return ics[i].MarketCap > ics[j].MarketCap
+	})
+	if d.TopN > 0 && len(ics) > d.TopN {
+		ics = ics[:d.TopN]
+	}
+	d.weigh(ics)
+	return ics
+}
+
+// GetIndexConstituents fetches the currencies ticker from the server and returns the index constituents.
+func (c *Connecter) GetIndexConstituents(d IndexDefinition, convert string) ([]IndexConstituent, error) {
+	ctReq := CurrenciesTickerRequest{
+		Interval: []string{"1d"},
+		Convert:  convert,
+		Status:   "active",
+		Sort:     "rank",
+	}
+	if d.TopN > 0 {
+		ctReq.PerPage = d.TopN + len(d.Exclude)
+		ctReq.Page = 1
+	}
+	ctResp, err := c.GetCurrenciesTicker(ctReq)
+	if err != nil {
+		return nil, err
+	}
+	return d.Constituents(ctResp), nil
+}
+
+// LevelsFromCandles computes the index level history of the constituents from their candles,
+// candles is keyed by constituent currency id.
+func (d IndexDefinition) LevelsFromCandles(ics []IndexConstituent, candles map[string][]CandlesResponse) []IndexLevel {
+	return d.levels(ics, candlesPrices(candles))
+}
+
+// LevelsFromSparkline computes the index level history of the constituents from their sparklines.
+func (d IndexDefinition) LevelsFromSparkline(ics []IndexConstituent, csResp []CurrenciesSparklineResponse) []IndexLevel {
+	return d.levels(ics, sparklinePrices(csResp))
+}
+
+// SaveIndexConstituents creates a csv file on disk with the id, market cap and weight of each constituent.
+func SaveIndexConstituents(ics []IndexConstituent, fileNameWithPath string) error {
+	f, err := os.Create(fileNameWithPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if err := w.Write([]string{"id", "market_cap", "weight"}); err != nil {
+		return err
+	}
+	for _, ic := range ics {
+		record := []string{
+			ic.ID,
+			strconv.FormatFloat(ic.MarketCap, 'f', -1, 64),
+			strconv.FormatFloat(ic.Weight, 'f', -1, 64),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Helper methods
+
+// levels computes the index level at every timestamp of the price series.
+// Units of each constituent are fixed at the first timestamp and at each rebalance, so that the
+// constituent weights match the target weights. On rebalance of a market cap weighted index,
+// market caps are estimated by scaling the initial market caps with the price change.
+func (d IndexDefinition) levels(ics []IndexConstituent, prices map[string]map[time.Time]float64) []IndexLevel {
+	level := d.BaseLevel
+	if level == 0 {
+		level = 1000
+	}
+
+	var ils []IndexLevel
+	var lastRebalance time.Time
+	var units []float64
+	initial := make([]float64, len(ics))
+	last := make([]float64, len(ics))
+	for _, ts := range seriesTimestamps(prices) {
+		complete := true
+		for i, ic := range ics {
+			if price, ok := prices[ic.ID][ts]; ok {
+				last[i] = price
+			}
+			if last[i] == 0 {
+				complete = false
+			}
+		}
+		if !complete {
+			continue
+		}
+
+		if units != nil {
+			level = 0
+			for i := range ics {
+				level += units[i] * last[i]
+			}
+		}
+
+		rebalanced := false
+		if units == nil || (d.Rebalance > 0 && ts.Sub(lastRebalance) >= d.Rebalance) {
+			if units == nil {
+				copy(initial, last)
+			}
+			current := make([]IndexConstituent, len(ics))
+			for i, ic := range ics {
+				current[i] = IndexConstituent{ID: ic.ID, MarketCap: ic.MarketCap * last[i] / initial[i]}
+			}
+			d.weigh(current)
+			units = make([]float64, len(ics))
+			for i := range ics {
+				units[i] = current[i].Weight * level / last[i]
+			}
+			lastRebalance = ts
+			rebalanced = true
+		}
+
+		il := IndexLevel{Timestamp: ts, Level: level, Rebalanced: rebalanced}
+		for i, ic := range ics {
+			il.Constituents = append(il.Constituents, IndexConstituent{
+				ID:        ic.ID,
+				MarketCap: ic.MarketCap * last[i] / initial[i],
+				Weight:    units[i] * last[i] / level,
+			})
+		}
+		ils = append(ils, il)
+	}
+	return ils
+}
+
+// weigh sets the target weight of each constituent as per the index weighting and weight cap.
+func (d IndexDefinition) weigh(ics []IndexConstituent) {
+	if len(ics) == 0 {
+		return
+	}
+	var total float64
+	for _, ic := range ics {
+		total += ic.MarketCap
+	}
+	for i := range ics {
+		if d.Weighting == IndexEqualWeighted || total == 0 {
+			ics[i].Weight = 1 / float64(len(ics))
+		} else {
+			ics[i].Weight = ics[i].MarketCap / total
+		}
+	}
+	if d.MaxWeight <= 0 {
+		return
+	}
+	if d.MaxWeight*float64(len(ics)) < 1 {
+		// Cap can not be satisfied, equal weights are the closest.
+		for i := range ics {
+			ics[i].Weight = 1 / float64(len(ics))
+		}
+		return
+	}
+
+	// Cap the weights and redistribute the excess proportionally to the uncapped constituents, until none exceeds the cap.
+	capped := make([]bool, len(ics))
+	for {
+		var excess, uncapped float64
+		for i := range ics {
+			if !capped[i] && ics[i].Weight > d.MaxWeight {
+				excess += ics[i].Weight - d.MaxWeight
+				ics[i].Weight = d.MaxWeight
+				capped[i] = true
+			}
+		}
+		if excess == 0 {
+			return
+		}
+		for i := range ics {
+			if !capped[i] {
+				uncapped += ics[i].Weight
+			}
+		}
+		if uncapped == 0 {
+			return
+		}
+		for i := range ics {
+			if !capped[i] {
+				ics[i].Weight += excess * ics[i].Weight / uncapped
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package gonomics
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestDominance tests market-cap dominance function.
+func TestDominance(t *testing.T) {
+	cds := Dominance([]CurrenciesTickerResponse{
+		{ID: "BTC", MarketCap: 600},
+		{ID: "ETH", MarketCap: 200},
+	}, GlobalTickerResponse{MarketCap: 1000})
+	if len(cds) != 2 || cds[0].DominancePct != 0.6 || cds[1].DominancePct != 0.2 {
+		t.Errorf("Something is wrong here, unexpected dominance %v.", cds)
+	}
+}
+
+// TestIndex tests index constituents, weights and level history functions.
+func TestIndex(t *testing.T) {
+	ctResp := []CurrenciesTickerResponse{
+		{ID: "USDT", MarketCap: 500},
+		{ID: "ETH", MarketCap: 200},
+		{ID: "BTC", MarketCap: 700},
+		{ID: "LTC", MarketCap: 100},
+		{ID: "DOGE", MarketCap: 50},
+	}
+	d := IndexDefinition{TopN: 3, MaxWeight: 0.5, Exclude: []string{"usdt"}, BaseLevel: 100, Rebalance: 48 * time.Hour}
+	ics := d.Constituents(ctResp)
+	if len(ics) != 3 || ics[0].ID != "BTC" || ics[2].ID != "LTC" {
+		t.Fatalf("Something is wrong here, unexpected constituents %v.", ics)
+	}
+	if ics[0].Weight != 0.5 || math.Abs(ics[1].Weight-0.5*2/3) > 1e-9 {
+		t.Errorf("Something is wrong here, weights are not capped %v.", ics)
+	}
+
+	equal := IndexDefinition{TopN: 2, Weighting: IndexEqualWeighted}.Constituents(ctResp)
+	if equal[0].Weight != 0.5 || equal[1].Weight != 0.5 {
+		t.Errorf("Something is wrong here, unexpected equal weights %v.", equal)
+	}
+
+	// Level history.
+	startTime, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+	day := 24 * time.Hour
+	candles := map[string][]CandlesResponse{
+		"BTC": {{Timestamp: startTime, Close: 10}, {Timestamp: startTime.Add(day), Close: 20}, {Timestamp: startTime.Add(2 * day), Close: 20}},
+		"ETH": {{Timestamp: startTime, Close: 10}, {Timestamp: startTime.Add(day), Close: 10}, {Timestamp: startTime.Add(2 * day), Close: 10}},
+		"LTC": {{Timestamp: startTime, Close: 10}, {Timestamp: startTime.Add(day), Close: 10}, {Timestamp: startTime.Add(2 * day), Close: 10}},
+	}
+	ils := d.LevelsFromCandles(ics, candles)
+	if len(ils) != 3 {
+		t.Fatalf("Something is wrong here, expected 3 levels, got %v.", len(ils))
+	}
+	if ils[0].Level != 100 || math.Abs(ils[1].Level-150) > 1e-9 || math.Abs(ils[2].Level-150) > 1e-9 {
+		t.Errorf("Something is wrong here, unexpected levels %v %v %v.", ils[0].Level, ils[1].Level, ils[2].Level)
+	}
+	if !ils[0].Rebalanced || ils[1].Rebalanced || !ils[2].Rebalanced || math.Abs(ils[2].Constituents[0].Weight-0.5) > 1e-9 {
+		t.Error("Something is wrong here, index is not rebalanced periodically.")
+	}
+
+	// CSV export.
+	name := filepath.Join(os.TempDir(), "gonomics_index_constituents.csv")
+	defer os.Remove(name)
+	if err := SaveIndexConstituents(ics, name); err != nil {
+		t.Error(err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	csvData, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(csvData) != 4 || csvData[1][0] != "BTC" || csvData[1][2] != "0.5" {
+		t.Errorf("Something is wrong here, unexpected csv data %v.", csvData)
+	}
+}
@@ -0,0 +1,234 @@
+package gonomics
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API Key Pool.
+
+// Key selection strategies.
+const (
+	KeyRoundRobin string = "round-robin"
+	KeyByQuota    string = "quota"
+)
+
+// ErrNoKeyAvailable is returned, without calling the server, when no key of the pool can make the request,
+// because they are benched, out of quota, or have a free plan for a paid endpoint.
+var ErrNoKeyAvailable = errors.New("no api key available")
+
+// PoolKey represents one API key of a KeyPool.
+type PoolKey struct {
+	Key string
+	// PlanFree or PlanPaid, paid endpoints are only requested with paid keys.
+	Plan string
+	// Requests per day, UTC, zero means no limit.
+	DailyQuota int
+}
+
+// KeyStats represents the usage statistics of one API key of a KeyPool.
+type KeyStats struct {
+	// Masked key, only its last 4 characters.
+	Key      string
+	Plan     string
+	Requests int
+	Failures int
+	Benches  int
+	// Requests left today, -1 if the key has no quota.
+	Remaining    int
+	BenchedUntil time.Time
+}
+
+// KeyPool spreads the requests over many API keys, and benches a key for BenchDuration when it receives
+// an unauthorized or too many requests response, failing the request over to the next key.
+type KeyPool struct {
+	// KeyRoundRobin, default, or KeyByQuota for the key with the most requests left today.
+	Strategy string
+	// BenchDuration is how long a failing key is not used, default 1 minute.
+	BenchDuration time.Duration
+
+	mu   sync.Mutex
+	keys []*pooledKey
+	next int
+	now  func() time.Time
+}
+
+// pooledKey is one key of the pool with its statistics.
+type pooledKey struct {
+	PoolKey
+	day          string
+	dayRequests  int
+	requests     int
+	failures     int
+	benches      int
+	benchedUntil time.Time
+}
+
+// NewKeyPool creates a KeyPool of the keys.
+func NewKeyPool(keys ...PoolKey) (*KeyPool, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+	p := &KeyPool{BenchDuration: time.Minute, now: time.Now}
+	for _, k := range keys {
+		if k.Key == "" {
+			return nil, errors.New("key is required")
+		}
+		p.keys = append(p.keys, &pooledKey{PoolKey: k})
+	}
+	return p, nil
+}
+
+// SetKeyPool makes the Connecter request with the keys of the pool, instead of its own key, nil disables it.
+func (c *Connecter) SetKeyPool(p *KeyPool) {
+	c.keyPool = p
+}
+
+// Stats returns the usage statistics of the keys, in the pool order.
+func (p *KeyPool) Stats() []KeyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	stats := make([]KeyStats, 0, len(p.keys))
+	for _, k := range p.keys {
+		remaining := -1
+		if k.DailyQuota > 0 {
+			remaining = int(k.remaining(now))
+		}
+		masked := k.Key
+		if len(masked) > 4 {
+			masked = strings.Repeat("*", len(masked)-4) + masked[len(masked)-4:]
+		}
+		stats = append(stats, KeyStats{
+			Key:          masked,
+			Plan:         k.Plan,
+			Requests:     k.requests,
+			Failures:     k.failures,
+			Benches:      k.benches,
+			Remaining:    remaining,
+			BenchedUntil: k.benchedUntil,
+		})
+	}
+	return stats
+}
+
+// Helper methods
+
+// do makes the request with send and a pool key, failing over to the next key
+// on unauthorized and too many requests responses. failover is called with the failed status before every retry,
+// and its error stops the retries.
+func (p *KeyPool) do(req *http.Request, send func(*http.Request, string) (*http.Response, error), failover func(*http.Request, int) error) (*http.Response, error) {
+	paid := PlanRequirements[endpoint(req)].Paid
+	tried := make(map[*pooledKey]bool)
+	for {
+		k := p.pick(paid, tried)
+		if k == nil {
+			return nil, ErrNoKeyAvailable
+		}
+		tried[k] = true
+
+		resp, err := send(req, k.Key)
+		benched := err == nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusTooManyRequests)
+		p.record(k, err != nil || resp.StatusCode != http.StatusOK, benched)
+		if benched && p.available(paid, tried) {
+			resp.Body.Close()
+			if err := failover(req, resp.StatusCode); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return resp, err
+	}
+}
+
+// pick returns the next key for the request, or nil if none is available, and reserves one request of its quota,
+// so that concurrent requests never exceed it. Keys with the same requests left are picked in turn with KeyByQuota.
+func (p *KeyPool) pick(paid bool, tried map[*pooledKey]bool) *pooledKey {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	best := -1
+	for i := range p.keys {
+		j := (p.next + i) % len(p.keys)
+		k := p.keys[j]
+		if tried[k] || !k.usable(paid, now) {
+			continue
+		}
+		if best < 0 || k.remaining(now) > p.keys[best].remaining(now) {
+			best = j
+		}
+		if p.Strategy != KeyByQuota {
+			break
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	p.next = (best + 1) % len(p.keys)
+	k := p.keys[best]
+	day := now.UTC().Format("2006-01-02")
+	if k.day != day {
+		k.day, k.dayRequests = day, 0
+	}
+	k.dayRequests++
+	return k
+}
+
+// available reports whether a key not tried yet can make the request.
+func (p *KeyPool) available(paid bool, tried map[*pooledKey]bool) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for _, k := range p.keys {
+		if !tried[k] && k.usable(paid, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// record counts one request of the key, whose quota is already reserved by pick, and benches it if needed.
+func (p *KeyPool) record(k *pooledKey, failed, bench bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	k.requests++
+	if failed {
+		k.failures++
+	}
+	if bench {
+		k.benches++
+		k.benchedUntil = now.Add(p.BenchDuration)
+	}
+}
+
+// redact replaces the pool keys in s.
+func (p *KeyPool) redact(s string) string {
+	for _, k := range p.keys {
+		s = strings.Replace(s, k.Key, redacted, -1)
+	}
+	return s
+}
+
+// usable reports whether the key can make a request now.
+func (k *pooledKey) usable(paid bool, now time.Time) bool {
+	if paid && k.Plan != PlanPaid {
+		return false
+	}
+	return !now.Before(k.benchedUntil) && k.remaining(now) > 0
+}
+
+// remaining returns the requests left today, or +Inf if the key has no quota.
+func (k *pooledKey) remaining(now time.Time) float64 {
+	if k.DailyQuota <= 0 {
+		return math.Inf(1)
+	}
+	if k.day != now.UTC().Format("2006-01-02") {
+		return float64(k.DailyQuota)
+	}
+	return float64(k.DailyQuota - k.dayRequests)
+}
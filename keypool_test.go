@@ -0,0 +1,171 @@
+package gonomics
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestKeyPool tests key rotation, paid endpoint routing, benching with failover, key statistics,
+// and the usage, metrics and logs of the failovers.
+func TestKeyPool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonomics_keypool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var keys []string
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		key := req.URL.Query().Get("key")
+		keys = append(keys, key)
+		if key == "free-2" {
+			return newTestResponse(req, http.StatusTooManyRequests, ""), nil
+		}
+		return newTestResponse(req, http.StatusOK, `[]`), nil
+	})
+	p, err := NewKeyPool(
+		PoolKey{Key: "free-1", Plan: PlanFree},
+		PoolKey{Key: "free-2", Plan: PlanFree},
+		PoolKey{Key: "paid-1", Plan: PlanPaid, DailyQuota: 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetKeyPool(p)
+	u, err := NewUsageTracker(filepath.Join(dir, "usage.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetUsageTracker(u)
+	m := NewMetrics()
+	c.SetMetrics(m)
+	var logs bytes.Buffer
+	c.SetLogger(NewStdLogger(log.New(&logs, "", 0)), LogWarn)
+
+	// free-2 is benched and the request fails over to paid-1.
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetMarkets(MarketsRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"free-1", "free-2", "paid-1", "free-1"}
+	if len(keys) != len(want) {
+		t.Fatalf("Something is wrong here, expected keys %v, got %v.", want, keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("Something is wrong here, expected keys %v, got %v.", want, keys)
+			break
+		}
+	}
+
+	if daily, _ := u.Used(EndpointMarkets, time.Now()); daily != 4 {
+		t.Errorf("Something is wrong here, expected 4 counted server calls, got %v.", daily)
+	}
+	var text strings.Builder
+	m.WriteTo(&text)
+	for _, want := range []string{
+		`nomics_requests_total{endpoint="markets",class="2xx"} 3`,
+		`nomics_requests_total{endpoint="markets",class="4xx"} 1`,
+		`nomics_retries_total{endpoint="markets"} 1`,
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("Something is wrong here, metrics do not contain %v :\n%v", want, text.String())
+		}
+	}
+	if !strings.Contains(logs.String(), "warn request retried endpoint=markets status=429") {
+		t.Errorf("Something is wrong here, expected retry log, got %v.", logs.String())
+	}
+
+	// Only paid-1 may request paid endpoints, and its quota is used up.
+	if _, err := c.GetGlobalTicker(GlobalTickerRequest{}); !errors.Is(err, ErrNoKeyAvailable) {
+		t.Errorf("Something is wrong here, expected no key available, got %v.", err)
+	}
+
+	stats := p.Stats()
+	if stats[1].Benches != 1 || stats[1].Failures != 1 || stats[1].BenchedUntil.IsZero() {
+		t.Errorf("Something is wrong here, unexpected benched key stats %+v.", stats[1])
+	}
+	if stats[2].Key != "**id-1" || stats[2].Remaining != 0 || stats[0].Remaining != -1 || stats[0].Requests != 2 {
+		t.Errorf("Something is wrong here, unexpected key stats %+v.", stats)
+	}
+}
+
+// TestKeyPoolByQuota tests that keys with the same requests left are picked in turn.
+func TestKeyPoolByQuota(t *testing.T) {
+	var keys []string
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		keys = append(keys, req.URL.Query().Get("key"))
+		return newTestResponse(req, http.StatusOK, `[]`), nil
+	})
+	p, err := NewKeyPool(PoolKey{Key: "a"}, PoolKey{Key: "b"}, PoolKey{Key: "c", DailyQuota: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Strategy = KeyByQuota
+	c.SetKeyPool(p)
+
+	for i := 0; i < 4; i++ {
+		if _, err := c.GetMarkets(MarketsRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Join(keys, ",") != "a,b,a,b" {
+		t.Errorf("Something is wrong here, expected keys a,b,a,b, got %v.", keys)
+	}
+}
+
+// TestKeyPoolConcurrentQuota tests that concurrent requests never exceed the key quotas.
+func TestKeyPoolConcurrentQuota(t *testing.T) {
+	var mu sync.Mutex
+	keys := make(map[string]int)
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		keys[req.URL.Query().Get("key")]++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		return newTestResponse(req, http.StatusOK, `[]`), nil
+	})
+	p, err := NewKeyPool(PoolKey{Key: "a", DailyQuota: 5}, PoolKey{Key: "b", DailyQuota: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Strategy = KeyByQuota
+	c.SetKeyPool(p)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetMarkets(MarketsRequest{})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	unavailable := 0
+	for err := range errs {
+		if errors.Is(err, ErrNoKeyAvailable) {
+			unavailable++
+		} else if err != nil {
+			t.Error(err)
+		}
+	}
+	if keys["a"] != 5 || keys["b"] != 5 || unavailable != 10 {
+		t.Errorf("Something is wrong here, expected 5 requests per key and 10 unavailable, got %v and %v.", keys, unavailable)
+	}
+}
@@ -0,0 +1,171 @@
+package gonomics
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Logging.
+
+// LogLevel represents the level of a log entry.
+type LogLevel int
+
+// Log levels.
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+// redacted replaces the credentials in logs and errors.
+const redacted = "REDACTED"
+
+// keyParam matches the key query parameter value in urls.
+var keyParam = regexp.MustCompile(`([?&]key=)[^&\s"']*`)
+
+// String returns the level name.
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warn"
+	case LogError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// LogFields represents the fields of a log entry, like "endpoint", "status", "duration" and "bytes".
+type LogFields map[string]interface{}
+
+// Logger receives the Connecter log entries. The API key is always redacted from the messages and fields.
+type Logger interface {
+	Log(level LogLevel, msg string, fields LogFields)
+}
+
+// SetLogger makes the Connecter log every request to the logger, from the level.
+// Request start is logged at debug level, successful finish at info level with the status, duration and bytes,
+// failure at error level with the error, and retries with another key of the key pool at warn level with the failed status.
+func (c *Connecter) SetLogger(logger Logger, level LogLevel) {
+	c.logger = logger
+	c.logLevel = level
+}
+
+// NewStdLogger returns a Logger writing "level msg key=value ..." lines to the standard library logger.
+func NewStdLogger(l *log.Logger) Logger {
+	return stdLogger{l}
+}
+
+// stdLogger is the standard library Logger.
+type stdLogger struct {
+	l *log.Logger
+}
+
+// Log writes the log entry with the fields sorted by name.
+func (s stdLogger) Log(level LogLevel, msg string, fields LogFields) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %v=%v", k, fields[k])
+	}
+	s.l.Print(b.String())
+}
+
+// Helper methods
+
+// log logs the entry, if the level is enabled, after redacting the credentials.
+func (c *Connecter) log(level LogLevel, msg string, fields LogFields) {
+	if c.logger == nil || level < c.logLevel {
+		return
+	}
+	for k, v := range fields {
+		switch v := v.(type) {
+		case string:
+			fields[k] = c.redact(v)
+		case error:
+			fields[k] = c.redact(v.Error())
+		}
+	}
+	c.logger.Log(level, c.redact(msg), fields)
+}
+
+// logCall logs the start and the finish of the call. The finish is logged when the response body is closed,
+// so that bytes is the number of bytes read.
+func (c *Connecter) logCall(next Doer) Doer {
+	return DoerFunc(func(call *Call) (*http.Response, error) {
+		start := time.Now()
+		c.log(LogDebug, "request started", LogFields{"endpoint": call.Endpoint, "url": call.Request.URL.String()})
+		resp, err := next.Do(call)
+		if err != nil {
+			c.log(LogError, "request failed", LogFields{"endpoint": call.Endpoint, "duration": time.Since(start), "error": err})
+			return resp, err
+		}
+		resp.Body = &countedBody{ReadCloser: resp.Body, close: func(n int64) {
+			c.log(LogInfo, "request finished", LogFields{"endpoint": call.Endpoint, "status": resp.StatusCode, "duration": time.Since(start), "bytes": n})
+		}}
+		return resp, nil
+	})
+}
+
+// redact replaces the key query parameter values and the API keys in s.
+func (c *Connecter) redact(s string) string {
+	s = keyParam.ReplaceAllString(s, "${1}"+redacted)
+	if c.apiKey != "" {
+		s = strings.Replace(s, c.apiKey, redacted, -1)
+	}
+	if c.keyPool != nil {
+		s = c.keyPool.redact(s)
+	}
+	return s
+}
+
+// redactError redacts the request url in net.http errors, which contains the key query parameter.
+func (c *Connecter) redactError(err error) error {
+	if uerr, ok := err.(*url.Error); ok {
+		uerr.URL = c.redact(uerr.URL)
+	}
+	return err
+}
+
+// countedBody counts the bytes read from the body and calls close with the count once it is closed.
+type countedBody struct {
+	io.ReadCloser
+	n      int64
+	close  func(n int64)
+	closed bool
+}
+
+// Read reads from the body and counts the bytes.
+func (b *countedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// Close closes the body and calls close once.
+func (b *countedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if !b.closed {
+		b.closed = true
+		b.close(b.n)
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+package gonomics
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestLogger tests request logging levels and API key redaction.
+func TestLogger(t *testing.T) {
+	c := New(demoAPIKey)
+	fail := false
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		return newTestResponse(req, http.StatusOK, `[{"currency":"BTC","rate":"50000","timestamp":"2021-01-01T00:00:00Z"}]`), nil
+	})
+	var buf bytes.Buffer
+	c.SetLogger(NewStdLogger(log.New(&buf, "", 0)), LogDebug)
+
+	if _, err := c.GetExchangeRates(ExchangeRatesRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	logs := buf.String()
+	if !strings.Contains(logs, "debug request started") || !strings.Contains(logs, "url=https://api.nomics.com/v1/exchange-rates") {
+		t.Errorf("Something is wrong here, unexpected start log %v.", logs)
+	}
+	if !strings.Contains(logs, "info request finished") || !strings.Contains(logs, "bytes=70") || !strings.Contains(logs, "status=200") {
+		t.Errorf("Something is wrong here, unexpected finish log %v.", logs)
+	}
+
+	// Transport errors contain the url, with the key.
+	fail = true
+	buf.Reset()
+	c.SetLogger(NewStdLogger(log.New(&buf, "", 0)), LogInfo)
+	_, err := c.GetExchangeRates(ExchangeRatesRequest{})
+	if err == nil || strings.Contains(err.Error(), demoAPIKey) || !strings.Contains(err.Error(), "key="+redacted) {
+		t.Errorf("Something is wrong here, expected redacted error, got %v.", err)
+	}
+	logs = buf.String()
+	if strings.Contains(logs, "debug") || !strings.Contains(logs, "error request failed") {
+		t.Errorf("Something is wrong here, unexpected failure log %v.", logs)
+	}
+	if strings.Contains(logs, demoAPIKey) {
+		t.Errorf("Something is wrong here, api key is logged %v.", logs)
+	}
+}
@@ -0,0 +1,230 @@
+package gonomics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Market Graph.
+
+// MarketGraph represents the trading graph formed by the markets, where currencies are nodes
+// and each market is an edge between its base and quote currency on its exchange.
+// Markets can be traded in both directions, buying or selling the base currency.
+type MarketGraph struct {
+	Markets   []MarketsResponse `json:"markets"`
+	FetchedAt time.Time         `json:"fetched_at"`
+
+	// exchange -> currency -> neighbour currency -> market.
+	edges map[string]map[string]map[string]MarketsResponse
+}
+
+// ConversionStep represents one conversion from currency to currency through the market.
+// If From is the Market base, the base is sold, otherwise it is bought.
+type ConversionStep struct {
+	From   string
+	To     string
+	Market MarketsResponse
+}
+
+// NewMarketGraph creates a brand new MarketGraph from the markets.
+func NewMarketGraph(mResp []MarketsResponse) *MarketGraph {
+	g := &MarketGraph{
+		Markets:   mResp,
+		FetchedAt: time.Now(),
+	}
+	g.build()
+	return g
+}
+
+// GetMarketGraph fetches the markets from the server and returns the MarketGraph of them.
+func (c *Connecter) GetMarketGraph(mReq MarketsRequest) (*MarketGraph, error) {
+	if mReq.Format != "" && mReq.Format != "json" {
+		return nil, fmt.Errorf("format %v is not supported for the market graph", mReq.Format)
+	}
+	mResp, err := c.GetMarkets(mReq)
+	if err != nil {
+		return nil, err
+	}
+	return NewMarketGraph(mResp), nil
+}
+
+// LoadMarketGraph reads the MarketGraph saved by MarketGraph.Save from the file on disk.
+func LoadMarketGraph(fileNameWithPath string) (*MarketGraph, error) {
+	f, err := os.Open(fileNameWithPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var g MarketGraph
+	if err := json.NewDecoder(f).Decode(&g); err != nil {
+		return nil, err
+	}
+	g.build()
+	return &g, nil
+}
+
+// Save writes the MarketGraph to a new json file on disk, so that it can be loaded again with LoadMarketGraph.
+func (g *MarketGraph) Save(fileNameWithPath string) error {
+	f, err := os.Create(fileNameWithPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(g)
+}
+
+// Exchanges returns the sorted ids of the exchanges listing base / quote market.
+func (g *MarketGraph) Exchanges(base, quote string) []string {
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+	seen := make(map[string]bool)
+	var exchanges []string
+	for _, m := range g.Markets {
+		if strings.ToUpper(m.Base) == base && strings.ToUpper(m.Quote) == quote && !seen[m.Exchange] {
+			seen[m.Exchange] = true
+			exchanges = append(exchanges, m.Exchange)
+		}
+	}
+	sort.Strings(exchanges)
+	return exchanges
+}
+
+// ShortestPath returns the conversion path from currency to currency with the fewest steps on the exchange.
+// If exchange is empty, markets of all the exchanges are used.
+func (g *MarketGraph) ShortestPath(exchange, from, to string) ([]ConversionStep, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	adj := g.adjacency(exchange)
+	if _, ok := adj[from]; !ok {
+		return nil, fmt.Errorf("currency %v is not listed on exchange %v", from, exchange)
+	}
+	if from == to {
+		return []ConversionStep{}, nil
+	}
+
+	// Breadth first search, visiting neighbours in sorted order for a deterministic path.
+	prev := map[string]ConversionStep{from: {}}
+	queue := []string{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, next := range sortedNeighbours(adj[cur]) {
+			if _, ok := prev[next]; ok {
+				continue
+			}
+			prev[next] = ConversionStep{From: cur, To: next, Market: adj[cur][next]}
+			if next == to {
+				var path []ConversionStep
+				for c := to; c != from; c = prev[c].From {
+					path = append([]ConversionStep{prev[c]}, path...)
+				}
+				return path, nil
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil, fmt.Errorf("no conversion path from %v to %v on exchange %v", from, to, exchange)
+}
+
+// TriangularCycles returns all the conversion cycles of three distinct currencies on the exchange,
+// like BTC -> ETH -> USDT -> BTC. Each cycle is returned once per direction, starting from
+// its alphabetically smallest currency. If exchange is empty, markets of all the exchanges are used.
+func (g *MarketGraph) TriangularCycles(exchange string) [][]ConversionStep {
+	adj := g.adjacency(exchange)
+	var cycles [][]ConversionStep
+	for _, a := range sortedCurrencies(adj) {
+		for _, b := range sortedNeighbours(adj[a]) {
+			if b <= a {
+				continue
+			}
+			for _, c := range sortedNeighbours(adj[b]) {
+				if c <= a || c == b {
+					continue
+				}
+				if _, ok := adj[c][a]; !ok {
+					continue
+				}
+				cycles = append(cycles, []ConversionStep{
+					{From: a, To: b, Market: adj[a][b]},
+					{From: b, To: c, Market: adj[b][c]},
+					{From: c, To: a, Market: adj[c][a]},
+				})
+			}
+		}
+	}
+	return cycles
+}
+
+// Helper methods
+
+// build creates the adjacency of the markets per exchange.
+func (g *MarketGraph) build() {
+	g.edges = make(map[string]map[string]map[string]MarketsResponse)
+	for _, m := range g.Markets {
+		base, quote := strings.ToUpper(m.Base), strings.ToUpper(m.Quote)
+		if base == "" || quote == "" || base == quote {
+			continue
+		}
+		adj, ok := g.edges[m.Exchange]
+		if !ok {
+			adj = make(map[string]map[string]MarketsResponse)
+			g.edges[m.Exchange] = adj
+		}
+		addEdge(adj, base, quote, m)
+		addEdge(adj, quote, base, m)
+	}
+}
+
+// adjacency returns the adjacency of the exchange, or of all the exchanges combined if exchange is empty.
+func (g *MarketGraph) adjacency(exchange string) map[string]map[string]MarketsResponse {
+	if exchange != "" {
+		return g.edges[exchange]
+	}
+	exchanges := make([]string, 0, len(g.edges))
+	for e := range g.edges {
+		exchanges = append(exchanges, e)
+	}
+	sort.Strings(exchanges)
+	all := make(map[string]map[string]MarketsResponse)
+	for _, e := range exchanges {
+		for from, neighbours := range g.edges[e] {
+			for to, m := range neighbours {
+				addEdge(all, from, to, m)
+			}
+		}
+	}
+	return all
+}
+
+// addEdge adds the market edge from currency to currency, keeping the first market if already present.
+func addEdge(adj map[string]map[string]MarketsResponse, from, to string, m MarketsResponse) {
+	if adj[from] == nil {
+		adj[from] = make(map[string]MarketsResponse)
+	}
+	if _, ok := adj[from][to]; !ok {
+		adj[from][to] = m
+	}
+}
+
+// sortedCurrencies returns the currencies of the adjacency, sorted.
+func sortedCurrencies(adj map[string]map[string]MarketsResponse) []string {
+	currencies := make([]string, 0, len(adj))
+	for c := range adj {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// sortedNeighbours returns the currencies reachable in one step, sorted.
+func sortedNeighbours(neighbours map[string]MarketsResponse) []string {
+	currencies := make([]string, 0, len(neighbours))
+	for c := range neighbours {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
@@ -0,0 +1,58 @@
+package gonomics
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestMarketGraph tests market graph path and cycle discovery functions.
+func TestMarketGraph(t *testing.T) {
+	g := NewMarketGraph([]MarketsResponse{
+		{Exchange: "binance", Market: "ETHBTC", Base: "ETH", Quote: "BTC"},
+		{Exchange: "binance", Market: "BTCUSDT", Base: "BTC", Quote: "USDT"},
+		{Exchange: "binance", Market: "ETHUSDT", Base: "ETH", Quote: "USDT"},
+		{Exchange: "binance", Market: "LTCETH", Base: "LTC", Quote: "ETH"},
+		{Exchange: "kraken", Market: "XBTUSDT", Base: "BTC", Quote: "USDT"},
+	})
+
+	if exchanges := g.Exchanges("btc", "usdt"); !reflect.DeepEqual(exchanges, []string{"binance", "kraken"}) {
+		t.Errorf("Something is wrong here, unexpected exchanges %v.", exchanges)
+	}
+
+	path, err := g.ShortestPath("binance", "LTC", "USDT")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(path) != 2 || path[0].Market.Market != "LTCETH" || path[1].Market.Market != "ETHUSDT" {
+		t.Errorf("Something is wrong here, unexpected path %v.", path)
+	}
+	if _, err := g.ShortestPath("kraken", "LTC", "USDT"); err == nil {
+		t.Error("Something is wrong here, path with unlisted currency should fail.")
+	}
+
+	cycles := g.TriangularCycles("binance")
+	if len(cycles) != 2 {
+		t.Errorf("Something is wrong here, expected 2 triangular cycles, got %v.", len(cycles))
+	}
+	for _, cycle := range cycles {
+		if cycle[0].From != cycle[2].To {
+			t.Errorf("Something is wrong here, cycle is not closed %v.", cycle)
+		}
+	}
+
+	// Save and load.
+	name := filepath.Join(os.TempDir(), "gonomics_market_graph.json")
+	defer os.Remove(name)
+	if err := g.Save(name); err != nil {
+		t.Error(err)
+	}
+	loaded, err := LoadMarketGraph(name)
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(loaded.Markets, g.Markets) || len(loaded.TriangularCycles("binance")) != 2 {
+		t.Error("Something is wrong here, loaded market graph is not matching with saved one.")
+	}
+}
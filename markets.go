@@ -47,7 +47,6 @@ func (c *Connecter) GetMarkets(mReq MarketsRequest) ([]MarketsResponse, error) {
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if mReq.Exchange != "" {
 		q.Add("exchange", mReq.Exchange)
 	}
@@ -66,7 +65,7 @@ func (c *Connecter) GetMarkets(mReq MarketsRequest) ([]MarketsResponse, error) {
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, mReq)
 	if err != nil {
 		return nil, err
 	}
@@ -130,7 +129,6 @@ func (c *Connecter) GetMarketsCapHistory(mchReq MarketsCapHistoryRequest) ([]Mar
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if mchReq.Start.IsZero() {
 		return nil, errors.New("start is required")
 	}
@@ -153,7 +151,7 @@ func (c *Connecter) GetMarketsCapHistory(mchReq MarketsCapHistoryRequest) ([]Mar
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, mchReq)
 	if err != nil {
 		return nil, err
 	}
@@ -242,7 +240,6 @@ func (c *Connecter) GetExchangeMarketsTicker(emtReq ExchangeMarketsTickerRequest
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if len(emtReq.Interval) > 0 {
 		q.Add("interval", strings.Join(emtReq.Interval[:], ","))
 	}
@@ -279,7 +276,7 @@ func (c *Connecter) GetExchangeMarketsTicker(emtReq ExchangeMarketsTickerRequest
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, emtReq)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,248 @@
+package gonomics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics.
+
+// defaultLatencyBuckets are the default request latency histogram buckets, in seconds.
+var defaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics collects the Connecter usage metrics and serves them in the Prometheus text exposition format:
+// server requests by endpoint and status class, server request latency histograms, bytes received, cache hits
+// retries with another key of the key pool, and rate limit wait histograms. Every retry is also a server request.
+type Metrics struct {
+	mu        sync.Mutex
+	buckets   []float64
+	requests  map[[2]string]uint64
+	latencies map[string]*latencyHistogram
+	bytes     map[string]uint64
+	cacheHits map[[2]string]uint64
+	retries   map[string]uint64
+	waits     map[string]*latencyHistogram
+}
+
+// latencyHistogram is the request latency, or rate limit wait, histogram of one endpoint.
+type latencyHistogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewMetrics creates Metrics with the latency histogram buckets in seconds, or the default buckets if none.
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = defaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		buckets:   buckets,
+		requests:  make(map[[2]string]uint64),
+		latencies: make(map[string]*latencyHistogram),
+		bytes:     make(map[string]uint64),
+		cacheHits: make(map[[2]string]uint64),
+		retries:   make(map[string]uint64),
+		waits:     make(map[string]*latencyHistogram),
+	}
+}
+
+// SetMetrics makes the Connecter record its requests in the metrics, nil disables it.
+// The same Metrics can be shared by many Connecters.
+func (c *Connecter) SetMetrics(m *Metrics) {
+	c.metrics = m
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+
+	b.WriteString("# HELP nomics_requests_total Requests to the Nomics API server by endpoint and status class.\n")
+	b.WriteString("# TYPE nomics_requests_total counter\n")
+	for _, k := range sortedPairs(m.requests) {
+		fmt.Fprintf(&b, "nomics_requests_total{endpoint=%v,class=%v} %v\n", quoteLabel(k[0]), quoteLabel(k[1]), m.requests[k])
+	}
+
+	m.writeHistograms(&b, "nomics_request_duration_seconds", "Request latency by endpoint.", m.latencies)
+
+	b.WriteString("# HELP nomics_response_bytes_total Response bytes received by endpoint.\n")
+	b.WriteString("# TYPE nomics_response_bytes_total counter\n")
+	endpoints := make([]string, 0, len(m.bytes))
+	for e := range m.bytes {
+		endpoints = append(endpoints, e)
+	}
+	sort.Strings(endpoints)
+	for _, e := range endpoints {
+		fmt.Fprintf(&b, "nomics_response_bytes_total{endpoint=%v} %v\n", quoteLabel(e), m.bytes[e])
+	}
+
+	b.WriteString("# HELP nomics_cache_hits_total Responses served from the cache by endpoint and cache.\n")
+	b.WriteString("# TYPE nomics_cache_hits_total counter\n")
+	for _, k := range sortedPairs(m.cacheHits) {
+		fmt.Fprintf(&b, "nomics_cache_hits_total{endpoint=%v,cache=%v} %v\n", quoteLabel(k[0]), quoteLabel(k[1]), m.cacheHits[k])
+	}
+
+	b.WriteString("# HELP nomics_retries_total Requests retried with another key of the key pool by endpoint.\n")
+	b.WriteString("# TYPE nomics_retries_total counter\n")
+	endpoints = endpoints[:0]
+	for e := range m.retries {
+		endpoints = append(endpoints, e)
+	}
+	sort.Strings(endpoints)
+	for _, e := range endpoints {
+		fmt.Fprintf(&b, "nomics_retries_total{endpoint=%v} %v\n", quoteLabel(e), m.retries[e])
+	}
+
+	m.writeHistograms(&b, "nomics_rate_limit_wait_seconds", "Time waited for the rate limit by endpoint.", m.waits)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Helper methods
+
+// instrument records the server request, its latency and its bytes in the Connecter metrics, if they are enabled.
+// Responses served from the caches are only counted as cache hits.
+func (c *Connecter) instrument(req *http.Request, resp *http.Response, err error, start time.Time) {
+	m := c.metrics
+	if m == nil {
+		return
+	}
+	e := endpoint(req)
+	m.observe(e, statusClass(resp, err), time.Since(start))
+	if err != nil {
+		return
+	}
+	resp.Body = &countedBody{ReadCloser: resp.Body, close: func(n int64) {
+		m.mu.Lock()
+		m.bytes[e] += uint64(n)
+		m.mu.Unlock()
+	}}
+}
+
+// observe records one request.
+func (m *Metrics) observe(endpoint, class string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[2]string{endpoint, class}]++
+	m.histogram(m.latencies, endpoint, d)
+}
+
+// wait records one rate limit wait. It does nothing on nil Metrics.
+func (m *Metrics) wait(endpoint string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.histogram(m.waits, endpoint, d)
+}
+
+// histogram records the duration in the histogram of the endpoint. Lock must be held by the caller.
+func (m *Metrics) histogram(hists map[string]*latencyHistogram, endpoint string, d time.Duration) {
+	h, ok := hists[endpoint]
+	if !ok {
+		h = &latencyHistogram{counts: make([]uint64, len(m.buckets))}
+		hists[endpoint] = h
+	}
+	seconds := d.Seconds()
+	for i, le := range m.buckets {
+		if seconds <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// writeHistograms writes the histograms of the endpoints. Lock must be held by the caller.
+func (m *Metrics) writeHistograms(b *strings.Builder, name, help string, hists map[string]*latencyHistogram) {
+	fmt.Fprintf(b, "# HELP %v %v\n", name, help)
+	fmt.Fprintf(b, "# TYPE %v histogram\n", name)
+	endpoints := make([]string, 0, len(hists))
+	for e := range hists {
+		endpoints = append(endpoints, e)
+	}
+	sort.Strings(endpoints)
+	for _, e := range endpoints {
+		h := hists[e]
+		var cumulative uint64
+		for i, le := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(b, "%v_bucket{endpoint=%v,le=\"%v\"} %v\n", name, quoteLabel(e), strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(b, "%v_bucket{endpoint=%v,le=\"+Inf\"} %v\n", name, quoteLabel(e), h.count)
+		fmt.Fprintf(b, "%v_sum{endpoint=%v} %v\n", name, quoteLabel(e), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(b, "%v_count{endpoint=%v} %v\n", name, quoteLabel(e), h.count)
+	}
+}
+
+// cacheHit records one response served from the cache. It does nothing on nil Metrics.
+func (m *Metrics) cacheHit(endpoint, cache string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.cacheHits[[2]string{endpoint, cache}]++
+	m.mu.Unlock()
+}
+
+// retry records one retry with another pool key. It does nothing on nil Metrics.
+func (m *Metrics) retry(endpoint string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.retries[endpoint]++
+	m.mu.Unlock()
+}
+
+// statusClass returns the status class of the response, like "2xx", or "error" for errors without a response.
+func statusClass(resp *http.Response, err error) string {
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return fmt.Sprintf("%dxx", apiErr.StatusCode/100)
+	case err != nil:
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", resp.StatusCode/100)
+}
+
+// sortedPairs returns the keys of the map, sorted.
+func sortedPairs(m map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
+
+// quoteLabel returns the quoted and escaped label value.
+func quoteLabel(v string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v) + `"`
+}
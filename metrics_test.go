@@ -0,0 +1,55 @@
+package gonomics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMetrics tests server request, latency, bytes, cache hit and rate limit wait metrics in the Prometheus text format.
+// Cache hits are not server requests.
+func TestMetrics(t *testing.T) {
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Query().Get("convert") == "BAD" {
+			return newTestResponse(req, http.StatusUnauthorized, ""), nil
+		}
+		return newTestResponse(req, http.StatusOK, `[{"id":"binance","base":"BTC","quote":"USDT"}]`), nil
+	})
+	c.EnableCache(10)
+	m := NewMetrics(0.5, 1)
+	c.SetMetrics(m)
+	c.SetRateLimit(1000, 10)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetMarkets(MarketsRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.GetGlobalTicker(GlobalTickerRequest{Convert: "BAD"}); err == nil {
+		t.Fatal("Something is wrong here, expected unauthorized error.")
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(rec.Body)
+	text := string(body)
+	for _, want := range []string{
+		`nomics_requests_total{endpoint="markets",class="2xx"} 1`,
+		`nomics_requests_total{endpoint="global-ticker",class="4xx"} 1`,
+		`nomics_request_duration_seconds_bucket{endpoint="markets",le="0.5"} 1`,
+		`nomics_request_duration_seconds_bucket{endpoint="markets",le="+Inf"} 1`,
+		`nomics_request_duration_seconds_count{endpoint="markets"} 1`,
+		`nomics_response_bytes_total{endpoint="markets"} 46`,
+		`nomics_cache_hits_total{endpoint="markets",cache="memory"} 1`,
+		"# TYPE nomics_request_duration_seconds histogram",
+		`nomics_rate_limit_wait_seconds_bucket{endpoint="markets",le="0.5"} 1`,
+		`nomics_rate_limit_wait_seconds_count{endpoint="global-ticker"} 1`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Something is wrong here, metrics do not contain %v :\n%v", want, text)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package gonomics
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// Middlewares.
+
+// Call represents one request of the Connecter to the server.
+type Call struct {
+	// Endpoint name, like EndpointCurrenciesTicker.
+	Endpoint string
+	// Request parameters struct, like CurrenciesTickerRequest.
+	Params interface{}
+	// net.http request, middlewares may modify it, like adding headers.
+	Request *http.Request
+}
+
+// Doer does a Call and returns its response.
+// Errors are *APIError for user or server error responses.
+type Doer interface {
+	Do(call *Call) (*http.Response, error)
+}
+
+// DoerFunc is a function Doer.
+type DoerFunc func(call *Call) (*http.Response, error)
+
+// Do calls f.
+func (f DoerFunc) Do(call *Call) (*http.Response, error) {
+	return f(call)
+}
+
+// Middleware wraps the next Doer in the chain.
+type Middleware func(next Doer) Doer
+
+// Use appends the middlewares to the Connecter middleware chain.
+// The first middleware is the outermost one, the chain ends with the cache, coalescing and server call.
+func (c *Connecter) Use(middlewares ...Middleware) {
+	c.middlewares = append(c.middlewares[:len(c.middlewares):len(c.middlewares)], middlewares...)
+}
+
+// LoggingMiddleware logs the endpoint, status code, duration, error and request url of every call to the logger.
+// The API key is not in the logged url, as it is only added to the request after the middleware chain.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(call *Call) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.Do(call)
+			if err != nil {
+				logger.Printf("nomics %v failed in %v, %v : %v", call.Endpoint, time.Since(start), call.Request.URL, err)
+				return resp, err
+			}
+			logger.Printf("nomics %v %v in %v, %v", call.Endpoint, resp.StatusCode, time.Since(start), call.Request.URL)
+			return resp, err
+		})
+	}
+}
+
+// TimingMiddleware calls observe with the endpoint, duration and error of every call.
+func TimingMiddleware(observe func(endpoint string, d time.Duration, err error)) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(call *Call) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.Do(call)
+			observe(call.Endpoint, time.Since(start), err)
+			return resp, err
+		})
+	}
+}
+
+// HeaderMiddleware sets the header values on every request, like tracing headers.
+func HeaderMiddleware(header http.Header) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(call *Call) (*http.Response, error) {
+			for k, v := range header {
+				call.Request.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+			}
+			return next.Do(call)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package gonomics
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMiddlewares tests the middleware chain order, call details and built-in middlewares.
+func TestMiddlewares(t *testing.T) {
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("X-Trace-Id") != "abc" {
+			return newTestResponse(req, http.StatusBadRequest, ""), nil
+		}
+		return newTestResponse(req, http.StatusOK, `[{"currency":"BTC","rate":"50000","timestamp":"2021-01-01T00:00:00Z"}]`), nil
+	})
+
+	var order []string
+	var params interface{}
+	c.Use(func(next Doer) Doer {
+		return DoerFunc(func(call *Call) (*http.Response, error) {
+			order = append(order, "outer")
+			params = call.Params
+			return next.Do(call)
+		})
+	}, func(next Doer) Doer {
+		return DoerFunc(func(call *Call) (*http.Response, error) {
+			order = append(order, "inner")
+			return next.Do(call)
+		})
+	})
+	var buf bytes.Buffer
+	var timed []string
+	var callErr error
+	c.Use(LoggingMiddleware(log.New(&buf, "", 0)), TimingMiddleware(func(endpoint string, d time.Duration, err error) {
+		timed = append(timed, endpoint)
+		callErr = err
+	}))
+
+	// Missing header makes the server fail.
+	_, err := c.GetExchangeRates(ExchangeRatesRequest{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Endpoint != EndpointExchangeRates {
+		t.Errorf("Something is wrong here, expected api error, got %v.", err)
+	}
+	if !errors.As(callErr, &apiErr) {
+		t.Errorf("Something is wrong here, expected middleware to see the api error, got %v.", callErr)
+	}
+
+	c.Use(HeaderMiddleware(http.Header{"X-Trace-Id": []string{"abc"}}))
+	if _, err := c.GetExchangeRates(ExchangeRatesRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "outer,inner,outer,inner" {
+		t.Errorf("Something is wrong here, unexpected middleware order %v.", order)
+	}
+	if _, ok := params.(ExchangeRatesRequest); !ok {
+		t.Errorf("Something is wrong here, unexpected call params %T.", params)
+	}
+	if len(timed) != 2 || timed[0] != EndpointExchangeRates {
+		t.Errorf("Something is wrong here, unexpected timed endpoints %v.", timed)
+	}
+	if logs := buf.String(); !strings.Contains(logs, "exchange-rates 200") || !strings.Contains(logs, apiServerPath+"/exchange-rates") ||
+		strings.Contains(logs, demoAPIKey) {
+		t.Errorf("Something is wrong here, unexpected logs %v.", logs)
+	}
+}
@@ -45,11 +45,13 @@ func (c *Connecter) GetOrdersSnapshot(osReq OrdersSnapshotRequest) (OrdersSnapsh
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if osReq.Exchange == "" {
 		return OrdersSnapshotResponse{}, errors.New("exchange is required")
 	}
 	q.Add("exchange", osReq.Exchange)
+	if err := c.checkCapability(osReq.Exchange, CapabilityOrdersSnapshot); err != nil {
+		return OrdersSnapshotResponse{}, err
+	}
 	if osReq.Market == "" {
 		return OrdersSnapshotResponse{}, errors.New("market is required")
 	}
@@ -66,7 +68,7 @@ func (c *Connecter) GetOrdersSnapshot(osReq OrdersSnapshotRequest) (OrdersSnapsh
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, osReq)
 	if err != nil {
 		return OrdersSnapshotResponse{}, err
 	}
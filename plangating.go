@@ -0,0 +1,155 @@
+package gonomics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Plans.
+
+// Plan tiers.
+const (
+	PlanFree string = "free"
+	PlanPaid string = "paid"
+)
+
+// ErrPaidPlanRequired is returned, without calling the server, when the request needs a paid plan and the Connecter plan is free.
+var ErrPaidPlanRequired = errors.New("paid plan required")
+
+// EndpointPlan represents the plan requirements of an endpoint.
+// Paid endpoints need a paid plan, partial paid endpoints are free with restricted parameters.
+type EndpointPlan struct {
+	Paid bool
+
+	// Intervals available with the free plan, other intervals need a paid plan. Empty means no restriction.
+	FreeIntervals []string
+	// Longest start to end range available with the free plan, zero means no restriction.
+	FreeMaxRange time.Duration
+}
+
+// PartialPaid reports whether the endpoint is free with restricted parameters.
+func (ep EndpointPlan) PartialPaid() bool {
+	return !ep.Paid && (len(ep.FreeIntervals) > 0 || ep.FreeMaxRange > 0)
+}
+
+// PlanRequirements are the plan requirements of the endpoints, endpoints not in it are free.
+// Modify it before making requests, if Nomics changes its plans.
+var PlanRequirements = map[string]EndpointPlan{
+	EndpointCurrenciesTicker:             {FreeIntervals: []string{"1h", "1d", "7d", "30d"}},
+	EndpointCurrenciesSupplyHistory:      {Paid: true},
+	EndpointMarketsCapHistory:            {FreeMaxRange: 365 * 24 * time.Hour},
+	EndpointExchangeMarketsTicker:        {Paid: true},
+	EndpointVolumeHistory:                {FreeMaxRange: 365 * 24 * time.Hour},
+	EndpointGlobalTicker:                 {Paid: true},
+	EndpointExchangesTicker:              {Paid: true},
+	EndpointExchangesVolumeHistory:       {Paid: true},
+	EndpointExchangesMetadata:            {Paid: true},
+	EndpointCandles:                      {Paid: true},
+	EndpointExchangeCandles:              {Paid: true},
+	EndpointMarketsCandles:               {Paid: true},
+	EndpointTrades:                       {Paid: true},
+	EndpointOrdersSnapshot:               {Paid: true},
+	EndpointCurrenciesPredictionsTicker:  {Paid: true},
+	EndpointCurrenciesPredictionsHistory: {Paid: true},
+}
+
+// SetPlan sets the plan tier of the API key, PlanFree or PlanPaid.
+// With PlanFree, requests needing a paid plan fail with ErrPaidPlanRequired. Empty plan disables the checks.
+func (c *Connecter) SetPlan(plan string) error {
+	if plan != "" && plan != PlanFree && plan != PlanPaid {
+		return fmt.Errorf("unknown plan %v", plan)
+	}
+	c.plan = plan
+	return nil
+}
+
+// NewWithPlan creates a brand new Nomics Connector, like New, with the plan tier of the API key, see SetPlan.
+func NewWithPlan(apiKey, plan string) (*Connecter, error) {
+	c := New(apiKey)
+	if err := c.SetPlan(plan); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Plan returns the plan tier of the API key, empty if unknown.
+func (c *Connecter) Plan() string {
+	return c.plan
+}
+
+// ProbePlan detects and sets the plan tier of the API key, by requesting the Global Ticker, a paid endpoint.
+// An unauthorized or forbidden response means a free plan only if the key is accepted by the Exchange Rates,
+// a free endpoint, so that an invalid or revoked key returns the error of the free endpoint instead.
+func (c *Connecter) ProbePlan() (string, error) {
+	cc := *c
+	cc.plan = ""
+	_, err := cc.GetGlobalTicker(GlobalTickerRequest{})
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		c.plan = PlanPaid
+	case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden):
+		if _, err := cc.GetExchangeRates(ExchangeRatesRequest{}); err != nil {
+			return "", err
+		}
+		c.plan = PlanFree
+	default:
+		return "", err
+	}
+	return c.plan, nil
+}
+
+// Helper methods
+
+// checkPlan returns ErrPaidPlanRequired if the plan is free and the endpoint, or its params, need a paid plan.
+func (c *Connecter) checkPlan(endpoint string, params interface{}) error {
+	if c.plan != PlanFree {
+		return nil
+	}
+	ep, ok := PlanRequirements[endpoint]
+	if !ok {
+		return nil
+	}
+	if ep.Paid {
+		return fmt.Errorf("%w : %v", ErrPaidPlanRequired, endpoint)
+	}
+
+	var intervals []string
+	var start, end time.Time
+	switch p := params.(type) {
+	case CurrenciesTickerRequest:
+		intervals = p.Interval
+	case MarketsCapHistoryRequest:
+		start, end = p.Start, p.End
+	case VolumeHistoryRequest:
+		start, end = p.Start, p.End
+	}
+	if len(ep.FreeIntervals) > 0 {
+		for _, i := range intervals {
+			if !containsString(ep.FreeIntervals, i) {
+				return fmt.Errorf("%w : %v interval %v", ErrPaidPlanRequired, endpoint, i)
+			}
+		}
+	}
+	if ep.FreeMaxRange > 0 && !start.IsZero() {
+		if end.IsZero() {
+			end = time.Now()
+		}
+		if end.Sub(start) > ep.FreeMaxRange {
+			return fmt.Errorf("%w : %v range longer than %v", ErrPaidPlanRequired, endpoint, ep.FreeMaxRange)
+		}
+	}
+	return nil
+}
+
+// containsString reports whether the values contain v.
+func containsString(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package gonomics
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestPlanGating tests paid plan checks before requests and plan probing.
+func TestPlanGating(t *testing.T) {
+	calls := 0
+	paid := false
+	revoked := false
+	if _, err := NewWithPlan(demoAPIKey, "fre"); err == nil {
+		t.Error("Something is wrong here, expected error for unknown plan.")
+	}
+	c, err := NewWithPlan(demoAPIKey, PlanFree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetPlan("fre"); err == nil || c.Plan() != PlanFree {
+		t.Error("Something is wrong here, expected error for unknown plan.")
+	}
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if revoked || (endpoint(req) == EndpointGlobalTicker && !paid) {
+			return newTestResponse(req, http.StatusUnauthorized, ""), nil
+		}
+		return newTestResponse(req, http.StatusOK, `[]`), nil
+	})
+
+	startTime, _ := time.Parse(time.RFC3339, "2019-01-01T00:00:00Z")
+	failing := []func() error{
+		func() error { _, err := c.GetGlobalTicker(GlobalTickerRequest{}); return err },
+		func() error {
+			_, err := c.GetCurrenciesTicker(CurrenciesTickerRequest{Interval: []string{"1d", "365d"}})
+			return err
+		},
+		func() error {
+			_, err := c.GetVolumeHistory(VolumeHistoryRequest{Start: startTime, End: startTime.Add(2 * 365 * 24 * time.Hour)})
+			return err
+		},
+	}
+	for i, f := range failing {
+		if err := f(); !errors.Is(err, ErrPaidPlanRequired) {
+			t.Errorf("Something is wrong here, expected paid plan error for request %v, got %v.", i, err)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("Something is wrong here, expected no server call, got %v.", calls)
+	}
+
+	if _, err := c.GetCurrenciesTicker(CurrenciesTickerRequest{Interval: []string{"1d", "30d"}}); err != nil {
+		t.Error(err)
+	}
+	if _, err := c.GetVolumeHistory(VolumeHistoryRequest{Start: startTime, End: startTime.Add(30 * 24 * time.Hour)}); err != nil {
+		t.Error(err)
+	}
+
+	// Probing.
+	if plan, err := c.ProbePlan(); err != nil || plan != PlanFree {
+		t.Errorf("Something is wrong here, expected free plan, got %v, %v.", plan, err)
+	}
+	// Invalid or revoked keys are not taken as a free plan.
+	revoked = true
+	var apiErr *APIError
+	if plan, err := c.ProbePlan(); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || plan != "" {
+		t.Errorf("Something is wrong here, expected unauthorized error, got %v, %v.", plan, err)
+	}
+	revoked = false
+	paid = true
+	if plan, err := c.ProbePlan(); err != nil || plan != PlanPaid {
+		t.Errorf("Something is wrong here, expected paid plan, got %v, %v.", plan, err)
+	}
+	if _, err := c.GetGlobalTicker(GlobalTickerRequest{}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,281 @@
+package gonomics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Portfolio.
+
+// Holding represents a holding of one currency in the Portfolio.
+type Holding struct {
+	ID       string
+	Quantity float64
+
+	// Total cost paid for the Quantity, in the Portfolio Convert currency.
+	CostBasis float64
+}
+
+// Portfolio represents holdings across many currencies, valued in the Convert currency.
+// Default Convert is USD.
+type Portfolio struct {
+	Convert  string
+	Holdings []Holding
+}
+
+// PortfolioValuation represents the valuation of the Portfolio at a time.
+// Change pct fields are fractions (0.05 is 5%), same as the pct fields received from the server,
+// and are weighted by the value of each asset.
+type PortfolioValuation struct {
+	Convert         string
+	Timestamp       time.Time
+	Value           float64
+	CostBasis       float64
+	PnL             float64
+	PnLPct          float64
+	OneHChangePct   float64
+	OneDChangePct   float64
+	SevenDChangePct float64
+	Assets          []AssetValuation
+}
+
+// AssetValuation represents the valuation of one holding, Included in PortfolioValuation.
+// Weight is the fraction of the Portfolio value allocated to this asset.
+type AssetValuation struct {
+	ID              string
+	Quantity        float64
+	Price           float64
+	Value           float64
+	CostBasis       float64
+	PnL             float64
+	PnLPct          float64
+	Weight          float64
+	OneHChangePct   float64
+	OneDChangePct   float64
+	SevenDChangePct float64
+}
+
+// Add adds quantity of the currency bought for cost to the Portfolio,
+// merging it with the existing holding of the same currency.
+func (p *Portfolio) Add(id string, quantity, cost float64) {
+	id = strings.ToUpper(id)
+	for i := range p.Holdings {
+		if strings.ToUpper(p.Holdings[i].ID) == id {
+			p.Holdings[i].Quantity += quantity
+			p.Holdings[i].CostBasis += cost
+			return
+		}
+	}
+	p.Holdings = append(p.Holdings, Holding{ID: id, Quantity: quantity, CostBasis: cost})
+}
+
+// Ids returns the currency ids of all the holdings.
+func (p Portfolio) Ids() []string {
+	ids := make([]string, 0, len(p.Holdings))
+	for _, h := range p.Holdings {
+		ids = append(ids, strings.ToUpper(h.ID))
+	}
+	return ids
+}
+
+// GetPortfolioValuation fetches the currencies ticker of all the holdings from the server
+// and returns the current PortfolioValuation.
+func (c *Connecter) GetPortfolioValuation(p Portfolio) (PortfolioValuation, error) {
+	if len(p.Holdings) == 0 {
+		return PortfolioValuation{}, errors.New("portfolio has no holdings")
+	}
+	ctResp, err := c.GetCurrenciesTicker(CurrenciesTickerRequest{
+		Ids:      p.Ids(),
+		Interval: []string{"1h", "1d", "7d"},
+		Convert:  p.Convert,
+	})
+	if err != nil {
+		return PortfolioValuation{}, err
+	}
+	return p.Valuate(ctResp)
+}
+
+// GetPortfolioHistory fetches the currencies sparkline of all the holdings from the server
+// and returns PortfolioValuation at each sparkline timestamp.
+func (c *Connecter) GetPortfolioHistory(p Portfolio, start, end time.Time) ([]PortfolioValuation, error) {
+	if len(p.Holdings) == 0 {
+		return nil, errors.New("portfolio has no holdings")
+	}
+	csResp, err := c.GetCurrenciesSparkline(CurrenciesSparklineRequest{
+		Ids:     p.Ids(),
+		Start:   start,
+		End:     end,
+		Convert: p.Convert,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.ValuateSparkline(csResp), nil
+}
+
+// Valuate values the Portfolio using the currencies ticker, which must be fetched in the Portfolio Convert currency.
+// It returns an error if any of the holdings is missing from the ticker.
+func (p Portfolio) Valuate(ctResp []CurrenciesTickerResponse) (PortfolioValuation, error) {
+	tickers := make(map[string]CurrenciesTickerResponse, len(ctResp))
+	for _, ct := range ctResp {
+		tickers[strings.ToUpper(ct.ID)] = ct
+	}
+
+	pv := PortfolioValuation{Convert: p.convert()}
+	for _, h := range p.Holdings {
+		ct, ok := tickers[strings.ToUpper(h.ID)]
+		if !ok {
+			return PortfolioValuation{}, fmt.Errorf("currency %v is missing from the ticker", h.ID)
+		}
+		av := newAssetValuation(h, ct.Price)
+		av.OneHChangePct = ct.OneH.PriceChangePct
+		av.OneDChangePct = ct.OneD.PriceChangePct
+		av.SevenDChangePct = ct.SevenD.PriceChangePct
+		pv.Assets = append(pv.Assets, av)
+		if ct.PriceTimestamp.After(pv.Timestamp) {
+			pv.Timestamp = ct.PriceTimestamp
+		}
+	}
+	pv.summarize()
+	return pv, nil
+}
+
+// ValuateSparkline values the Portfolio at each sparkline timestamp.
+// Sparklines must be fetched in the Portfolio Convert currency.
+func (p Portfolio) ValuateSparkline(csResp []CurrenciesSparklineResponse) []PortfolioValuation {
+	return p.valuateSeries(sparklinePrices(csResp))
+}
+
+// ValuateCandles values the Portfolio at each candle timestamp using the candle Close,
+// candles is keyed by holding currency id.
+// Note : candles are priced in USD, so the Portfolio Convert currency should be USD.
+func (p Portfolio) ValuateCandles(candles map[string][]CandlesResponse) []PortfolioValuation {
+	return p.valuateSeries(candlesPrices(candles))
+}
+
+// Helper methods
+
+// convert returns the Portfolio Convert currency, USD by default.
+func (p Portfolio) convert() string {
+	if p.Convert == "" {
+		return usdCurrency
+	}
+	return strings.ToUpper(p.Convert)
+}
+
+// valuateSeries values the Portfolio at every timestamp of the price series.
+// Missing prices are carried forward from the previous timestamp, and timestamps before
+// every holding has a price are skipped.
+func (p Portfolio) valuateSeries(prices map[string]map[time.Time]float64) []PortfolioValuation {
+	last := make(map[string]float64, len(p.Holdings))
+	var pvs []PortfolioValuation
+	for _, ts := range seriesTimestamps(prices) {
+		pv := PortfolioValuation{Convert: p.convert(), Timestamp: ts}
+		complete := true
+		for _, h := range p.Holdings {
+			id := strings.ToUpper(h.ID)
+			if price, ok := prices[id][ts]; ok {
+				last[id] = price
+			}
+			price, ok := last[id]
+			if !ok {
+				complete = false
+				continue
+			}
+			pv.Assets = append(pv.Assets, newAssetValuation(h, price))
+		}
+		if !complete {
+			continue
+		}
+		pv.summarize()
+		pvs = append(pvs, pv)
+	}
+	return pvs
+}
+
+// summarize computes the Portfolio totals, P&L, asset weights and value weighted interval changes.
+func (pv *PortfolioValuation) summarize() {
+	for _, av := range pv.Assets {
+		pv.Value += av.Value
+		pv.CostBasis += av.CostBasis
+	}
+	pv.PnL = pv.Value - pv.CostBasis
+	if pv.CostBasis != 0 {
+		pv.PnLPct = pv.PnL / pv.CostBasis
+	}
+	if pv.Value == 0 {
+		return
+	}
+	for i := range pv.Assets {
+		av := &pv.Assets[i]
+		av.Weight = av.Value / pv.Value
+		pv.OneHChangePct += av.Weight * av.OneHChangePct
+		pv.OneDChangePct += av.Weight * av.OneDChangePct
+		pv.SevenDChangePct += av.Weight * av.SevenDChangePct
+	}
+}
+
+// newAssetValuation values the holding at the price.
+func newAssetValuation(h Holding, price float64) AssetValuation {
+	av := AssetValuation{
+		ID:        strings.ToUpper(h.ID),
+		Quantity:  h.Quantity,
+		Price:     price,
+		Value:     h.Quantity * price,
+		CostBasis: h.CostBasis,
+	}
+	av.PnL = av.Value - av.CostBasis
+	if av.CostBasis != 0 {
+		av.PnLPct = av.PnL / av.CostBasis
+	}
+	return av
+}
+
+// sparklinePrices returns the price series of the sparklines keyed by currency id.
+func sparklinePrices(csResp []CurrenciesSparklineResponse) map[string]map[time.Time]float64 {
+	prices := make(map[string]map[time.Time]float64, len(csResp))
+	for _, cs := range csResp {
+		series := make(map[time.Time]float64, len(cs.Timestamps))
+		for i, ts := range cs.Timestamps {
+			if i < len(cs.Prices) {
+				series[ts] = cs.Prices[i]
+			}
+		}
+		prices[strings.ToUpper(cs.Currency)] = series
+	}
+	return prices
+}
+
+// candlesPrices returns the candle Close series of the candles keyed by currency id.
+func candlesPrices(candles map[string][]CandlesResponse) map[string]map[time.Time]float64 {
+	prices := make(map[string]map[time.Time]float64, len(candles))
+	for id, cResp := range candles {
+		series := make(map[time.Time]float64, len(cResp))
+		for _, c := range cResp {
+			series[c.Timestamp] = c.Close
+		}
+		prices[strings.ToUpper(id)] = series
+	}
+	return prices
+}
+
+// seriesTimestamps returns the sorted union of the timestamps of all the price series.
+func seriesTimestamps(prices map[string]map[time.Time]float64) []time.Time {
+	seen := make(map[time.Time]bool)
+	var timestamps []time.Time
+	for _, series := range prices {
+		for ts := range series {
+			if !seen[ts] {
+				seen[ts] = true
+				timestamps = append(timestamps, ts)
+			}
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i].Before(timestamps[j])
+	})
+	return timestamps
+}
@@ -0,0 +1,58 @@
+package gonomics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestPortfolioValuate tests portfolio valuation functions.
+func TestPortfolioValuate(t *testing.T) {
+	var p Portfolio
+	p.Add("BTC", 1, 30000)
+	p.Add("eth", 10, 10000)
+	p.Add("ETH", 10, 10000)
+
+	ctResp := []CurrenciesTickerResponse{
+		{ID: "BTC", Price: 40000, OneD: CurrenciesTickerIntervalResponse{PriceChangePct: 0.1}},
+		{ID: "ETH", Price: 1000, OneD: CurrenciesTickerIntervalResponse{PriceChangePct: -0.1}},
+	}
+	pv, err := p.Valuate(ctResp)
+	if err != nil {
+		t.Error(err)
+	}
+	if pv.Convert != "USD" || pv.Value != 60000 || pv.CostBasis != 50000 || pv.PnL != 10000 {
+		t.Errorf("Something is wrong here, unexpected valuation %v.", pv)
+	}
+	if len(pv.Assets) != 2 || pv.Assets[1].Quantity != 20 || math.Abs(pv.Assets[0].Weight-2.0/3) > 1e-9 {
+		t.Errorf("Something is wrong here, unexpected assets %v.", pv.Assets)
+	}
+	if math.Abs(pv.OneDChangePct-(0.1*2/3-0.1/3)) > 1e-9 {
+		t.Errorf("Something is wrong here, unexpected 1d change %v.", pv.OneDChangePct)
+	}
+	if _, err := p.Valuate(ctResp[:1]); err == nil {
+		t.Error("Something is wrong here, valuation with missing ticker should fail.")
+	}
+
+	// Historical valuation.
+	startTime, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+	day := 24 * time.Hour
+	pvs := p.ValuateCandles(map[string][]CandlesResponse{
+		"BTC": {{Timestamp: startTime, Close: 30000}, {Timestamp: startTime.Add(day), Close: 31000}},
+		"ETH": {{Timestamp: startTime.Add(day), Close: 700}, {Timestamp: startTime.Add(2 * day), Close: 800}},
+	})
+	if len(pvs) != 2 {
+		t.Errorf("Something is wrong here, expected 2 valuations, got %v.", len(pvs))
+	}
+	if pvs[1].Value != 31000+20*800 {
+		t.Errorf("Something is wrong here, missing price is not carried forward %v.", pvs[1].Value)
+	}
+
+	pvs = p.ValuateSparkline([]CurrenciesSparklineResponse{
+		{Currency: "BTC", Timestamps: []time.Time{startTime}, Prices: []float64{30000}},
+		{Currency: "ETH", Timestamps: []time.Time{startTime}, Prices: []float64{1000}},
+	})
+	if len(pvs) != 1 || pvs[0].Value != 50000 || pvs[0].PnL != 0 {
+		t.Errorf("Something is wrong here, unexpected sparkline valuation %v.", pvs)
+	}
+}
@@ -38,6 +38,24 @@ type CurrenciesPredictionsTickerPredictionsResponse struct {
 // GetCurrenciesPredictionsTicker fetches the currencies predictions ticker from the server
 // and returns array of CurrenciesPredictionsTickerResponse.
 func (c *Connecter) GetCurrenciesPredictionsTicker(cptReq CurrenciesPredictionsTickerRequest) ([]CurrenciesPredictionsTickerResponse, error) {
+	// Split long ids into sub-requests, merged in the ids order.
+	var merged []CurrenciesPredictionsTickerResponse
+	chunked, err := c.getChunked(cptReq.Ids, 0, 0, "", func(ids []string) error {
+		chunkReq := cptReq
+		chunkReq.Ids = ids
+		resp, err := c.GetCurrenciesPredictionsTicker(chunkReq)
+		merged = append(merged, resp...)
+		return err
+	}, func() {
+		sortByIds(merged, cptReq.Ids, func(i int) string { return merged[i].ID })
+	})
+	if err != nil {
+		return nil, err
+	}
+	if chunked {
+		return merged, nil
+	}
+
 	req, err := c.newRequest(currenciesPredictionsTickerURL)
 	if err != nil {
 		return nil, err
@@ -45,14 +63,13 @@ func (c *Connecter) GetCurrenciesPredictionsTicker(cptReq CurrenciesPredictionsT
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if len(cptReq.Ids) > 0 {
 		q.Add("ids", strings.Join(cptReq.Ids[:], ","))
 	}
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, cptReq)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +121,6 @@ func (c *Connecter) GetCurrenciesPredictionsHistory(cphReq CurrenciesPredictions
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if cphReq.ID != "" {
 		q.Add("id", cphReq.ID)
 	}
@@ -114,7 +130,7 @@ func (c *Connecter) GetCurrenciesPredictionsHistory(cphReq CurrenciesPredictions
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, cphReq)
 	if err != nil {
 		return CurrenciesPredictionsHistoryResponse{}, err
 	}
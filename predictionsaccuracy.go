@@ -0,0 +1,155 @@
+package gonomics
+
+import (
+	"math"
+	"sort"
+)
+
+// Currencies Predictions Accuracy.
+
+// PredictionsAccuracy represents accuracy statistics of the nomics predictions for one currency and interval,
+// computed from CurrenciesPredictionsHistoryResponse.
+// Percentage values are fractions (0.05 is 5%), same as the pct fields received from the server.
+type PredictionsAccuracy struct {
+	ID       string
+	Interval string
+
+	// Number of predictions which are already realized, i.e. have ActualPriceEnd.
+	Count int
+
+	// Mean absolute error between PriceEnd and ActualPriceEnd, in the price currency.
+	MAE float64
+
+	// Mean absolute percentage error between PriceEnd and ActualPriceEnd.
+	MAPE float64
+
+	// Root mean squared error between PriceEnd and ActualPriceEnd, in the price currency.
+	RMSE float64
+
+	// Fraction of predictions where the predicted price direction matched the realized one.
+	HitRate float64
+
+	// Predicted PriceChangePct versus realized change, bucketed by predicted change.
+	Calibration []PredictionsCalibrationBucket
+
+	// Server reported average errors, filled by AttachServerAccuracy from the predictions ticker.
+	AvgErrorPct    float64
+	AvgErrorPct7D  float64
+	AvgErrorPct30D float64
+}
+
+// PredictionsCalibrationBucket represents one calibration bucket, Included in PredictionsAccuracy.
+// All predictions with PriceChangePct in [MinChangePct, MaxChangePct) are included in the bucket.
+type PredictionsCalibrationBucket struct {
+	MinChangePct          float64
+	MaxChangePct          float64
+	Count                 int
+	AvgPredictedChangePct float64
+	AvgRealizedChangePct  float64
+}
+
+// EvaluatePredictions computes the accuracy statistics of the predictions history of one currency and interval.
+// Predictions which are not yet realized (ActualPriceEnd or PriceStart is 0) are ignored.
+// bucketWidth is the width of calibration buckets as a fraction, like 0.01 for 1% wide buckets,
+// calibration is not computed if bucketWidth is 0.
+func EvaluatePredictions(cphResp CurrenciesPredictionsHistoryResponse, bucketWidth float64) PredictionsAccuracy {
+	pa := PredictionsAccuracy{
+		ID:       cphResp.ID,
+		Interval: cphResp.Interval,
+	}
+
+	var sumAbs, sumAbsPct, sumSq float64
+	var hits int
+	buckets := make(map[int]*PredictionsCalibrationBucket)
+	for _, p := range cphResp.Predictions {
+		if p.ActualPriceEnd == 0 || p.PriceStart == 0 {
+			continue
+		}
+		pa.Count++
+
+		diff := p.PriceEnd - p.ActualPriceEnd
+		sumAbs += math.Abs(diff)
+		sumAbsPct += math.Abs(diff / p.ActualPriceEnd)
+		sumSq += diff * diff
+
+		realizedChangePct := (p.ActualPriceEnd - p.PriceStart) / p.PriceStart
+		if sign(p.PriceChangePct) == sign(realizedChangePct) {
+			hits++
+		}
+
+		if bucketWidth > 0 {
+			idx := int(math.Floor(p.PriceChangePct / bucketWidth))
+			b, ok := buckets[idx]
+			if !ok {
+				b = &PredictionsCalibrationBucket{
+					MinChangePct: float64(idx) * bucketWidth,
+					MaxChangePct: float64(idx+1) * bucketWidth,
+				}
+				buckets[idx] = b
+			}
+			b.Count++
+			b.AvgPredictedChangePct += p.PriceChangePct
+			b.AvgRealizedChangePct += realizedChangePct
+		}
+	}
+	if pa.Count == 0 {
+		return pa
+	}
+
+	n := float64(pa.Count)
+	pa.MAE = sumAbs / n
+	pa.MAPE = sumAbsPct / n
+	pa.RMSE = math.Sqrt(sumSq / n)
+	pa.HitRate = float64(hits) / n
+
+	for _, b := range buckets {
+		b.AvgPredictedChangePct /= float64(b.Count)
+		b.AvgRealizedChangePct /= float64(b.Count)
+		pa.Calibration = append(pa.Calibration, *b)
+	}
+	sort.Slice(pa.Calibration, func(i, j int) bool {
+		return pa.Calibration[i].MinChangePct < pa.Calibration[j].MinChangePct
+	})
+	return pa
+}
+
+// EvaluatePredictionsHistory computes the accuracy statistics for each of the predictions histories,
+// one PredictionsAccuracy per currency and interval, in the same order.
+func EvaluatePredictionsHistory(cphResps []CurrenciesPredictionsHistoryResponse, bucketWidth float64) []PredictionsAccuracy {
+	pas := make([]PredictionsAccuracy, 0, len(cphResps))
+	for _, cphResp := range cphResps {
+		pas = append(pas, EvaluatePredictions(cphResp, bucketWidth))
+	}
+	return pas
+}
+
+// AttachServerAccuracy fills the server reported AvgErrorPct, AvgErrorPct7D and AvgErrorPct30D of each
+// PredictionsAccuracy from the predictions ticker, matching on currency id and interval,
+// so that the computed MAPE can be compared with the server reported average error.
+func AttachServerAccuracy(pas []PredictionsAccuracy, cptResp []CurrenciesPredictionsTickerResponse) {
+	for i := range pas {
+		for _, cpt := range cptResp {
+			if cpt.ID != pas[i].ID {
+				continue
+			}
+			for _, p := range cpt.Predictions {
+				if p.Interval == pas[i].Interval {
+					pas[i].AvgErrorPct = p.AvgErrorPct
+					pas[i].AvgErrorPct7D = p.AvgErrorPct7D
+					pas[i].AvgErrorPct30D = p.AvgErrorPct30D
+				}
+			}
+		}
+	}
+}
+
+// sign returns -1, 0 or 1 depending on the sign of v.
+func sign(v float64) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+package gonomics
+
+import (
+	"math"
+	"testing"
+)
+
+// TestEvaluatePredictions tests predictions accuracy evaluation function.
+func TestEvaluatePredictions(t *testing.T) {
+	cphResp := CurrenciesPredictionsHistoryResponse{
+		ID:       "BTC",
+		Interval: "7d",
+		Predictions: []CurrenciesPredictionsHistoryPredictionsResponse{
+			{PriceStart: 100, PriceEnd: 110, PriceChangePct: 0.1, ActualPriceEnd: 100},
+			{PriceStart: 100, PriceEnd: 95, PriceChangePct: -0.05, ActualPriceEnd: 90},
+			{PriceStart: 100, PriceEnd: 120, PriceChangePct: 0.2},
+		},
+	}
+	pa := EvaluatePredictions(cphResp, 0.1)
+	if pa.Count != 2 {
+		t.Errorf("Something is wrong here, expected 2 realized predictions, got %v.", pa.Count)
+	}
+	if pa.MAE != 7.5 {
+		t.Errorf("Something is wrong here, expected MAE 7.5, got %v.", pa.MAE)
+	}
+	if math.Abs(pa.MAPE-(0.1+5.0/90)/2) > 1e-9 {
+		t.Errorf("Something is wrong here, unexpected MAPE %v.", pa.MAPE)
+	}
+	if math.Abs(pa.RMSE-math.Sqrt((100+25)/2.0)) > 1e-9 {
+		t.Errorf("Something is wrong here, unexpected RMSE %v.", pa.RMSE)
+	}
+	if pa.HitRate != 0.5 {
+		t.Errorf("Something is wrong here, expected hit rate 0.5, got %v.", pa.HitRate)
+	}
+	if len(pa.Calibration) != 2 || pa.Calibration[0].MinChangePct >= pa.Calibration[1].MinChangePct {
+		t.Errorf("Something is wrong here, unexpected calibration buckets %v.", pa.Calibration)
+	}
+
+	pas := []PredictionsAccuracy{pa}
+	AttachServerAccuracy(pas, []CurrenciesPredictionsTickerResponse{
+		{ID: "BTC", Predictions: []CurrenciesPredictionsTickerPredictionsResponse{
+			{Interval: "1d", AvgErrorPct: 0.01},
+			{Interval: "7d", AvgErrorPct: 0.03, AvgErrorPct7D: 0.04, AvgErrorPct30D: 0.05},
+		}},
+	})
+	if pas[0].AvgErrorPct != 0.03 || pas[0].AvgErrorPct7D != 0.04 || pas[0].AvgErrorPct30D != 0.05 {
+		t.Error("Something is wrong here, server reported average errors are not attached.")
+	}
+}
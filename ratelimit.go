@@ -0,0 +1,80 @@
+package gonomics
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Rate Limiting.
+
+// rateLimiter spaces the server requests to a rate, allowing bursts of up to burst requests.
+// Requests reserve their turn in arrival order, so that waiting requests are not starved.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	// tat is the theoretical arrival time of the next request at the exact rate.
+	tat time.Time
+	now func() time.Time
+}
+
+// SetRateLimit limits the server requests of the Connecter to perSecond requests per second, with bursts of up to
+// burst requests, default 1. Requests over the limit wait for their turn, or until their context is done.
+// Connecters returned by WithContext share the limit, so that batches, backfill jobs and every other request
+// of the Connecter are limited together. Zero perSecond disables the limit.
+// Every request to the server waits for the limit, including the retries with another key of the key pool,
+// while responses served from the caches do not. The waits are recorded in the Metrics, if set.
+func (c *Connecter) SetRateLimit(perSecond float64, burst int) {
+	if perSecond <= 0 {
+		c.limiter = nil
+		return
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	c.limiter = &rateLimiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+		burst:    burst,
+		now:      time.Now,
+	}
+}
+
+// Helper methods
+
+// wait waits for the turn of one request, or until the ctx is done, and returns the time waited.
+// It does nothing on nil rateLimiter.
+func (l *rateLimiter) wait(ctx context.Context) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+	delay := l.reserve()
+	if delay <= 0 {
+		return 0, nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return delay, nil
+	case <-ctx.Done():
+		return delay, ctx.Err()
+	}
+}
+
+// reserve reserves the turn of one request and returns how long to wait for it.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	tat := l.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	l.tat = tat.Add(l.interval)
+	// Up to burst requests may run ahead of the exact rate.
+	if delay := tat.Add(-time.Duration(l.burst-1) * l.interval).Sub(now); delay > 0 {
+		return delay
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+package gonomics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRateLimit tests request spacing with bursts, shared limits of batches and cancelled waits.
+func TestRateLimit(t *testing.T) {
+	c := New(demoAPIKey)
+	c.SetRateLimit(10, 2)
+	now := time.Now()
+	c.limiter.now = func() time.Time { return now }
+	var delays []time.Duration
+	for i := 0; i < 4; i++ {
+		delays = append(delays, c.limiter.reserve())
+	}
+	want := []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Fatalf("Something is wrong here, expected delays %v, got %v.", want, delays)
+		}
+	}
+
+	// Batch requests wait for the limit.
+	var mu sync.Mutex
+	var sent []time.Time
+	c = New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		sent = append(sent, time.Now())
+		mu.Unlock()
+		return newTestResponse(req, http.StatusOK, `[]`), nil
+	})
+	c.SetRateLimit(50, 1)
+	startTime, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+	var cReqs []CandlesRequest
+	for _, currency := range []string{"BTC", "ETH", "XRP", "LTC", "ADA"} {
+		cReqs = append(cReqs, CandlesRequest{Interval: "1d", Currency: currency, Start: startTime})
+	}
+	start := time.Now()
+	if _, err := c.GetCandlesBatch(cReqs, BatchOptions{Concurrency: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 80*time.Millisecond || len(sent) != 5 {
+		t.Errorf("Something is wrong here, expected 5 requests spaced by 20ms, got %v in %v.", len(sent), d)
+	}
+
+	// Waiting requests give up once their context is done.
+	c.SetRateLimit(0.001, 1)
+	c.GetMarkets(MarketsRequest{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.WithContext(ctx).GetMarkets(MarketsRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Something is wrong here, expected deadline exceeded error, got %v.", err)
+	}
+}
@@ -0,0 +1,668 @@
+package gonomics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Candle Store.
+
+// storeSegmentSize is the number of candles in a segment file, before a new segment is started.
+const storeSegmentSize = 10000
+
+// CandleStore is a local, file based store of candle series. Each series is kept in its own directory
+// as append-only segment files of json lines, along with the last synced timestamp of the series
+// and the timestamps range of every segment, so that range queries only read the segments overlapping the range.
+// The segment files are written before the state of the series, and the records written after the last state
+// write, as by a crash, are recovered from the segment files when the series is next read.
+type CandleStore struct {
+	dir         string
+	segmentSize int
+	mu          sync.Mutex
+}
+
+// SeriesInfo represents the state of one series in the CandleStore.
+// Count is the number of appended candles, including the candles appended again with the same timestamp,
+// which queries return once. SegmentCount and SegmentBytes are the candles and size of the last segment file.
+type SeriesInfo struct {
+	Name          string         `json:"name"`
+	Interval      string         `json:"interval"`
+	LastTimestamp time.Time      `json:"last_timestamp"`
+	Count         int            `json:"count"`
+	Segments      int            `json:"segments"`
+	SegmentCount  int            `json:"segment_count"`
+	SegmentBytes  int64          `json:"segment_bytes"`
+	Ranges        []SegmentRange `json:"ranges,omitempty"`
+}
+
+// SegmentRange represents the timestamps range of the candles of one segment file.
+type SegmentRange struct {
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// storeRecord is one candle of a series, kept as raw json of its response type.
+type storeRecord struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Raw       json.RawMessage `json:"-"`
+}
+
+// OpenCandleStore opens the CandleStore in the directory, creating the directory if needed.
+func OpenCandleStore(dir string) (*CandleStore, error) {
+	if dir == "" {
+		return nil, errors.New("store directory is required")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &CandleStore{dir: dir, segmentSize: storeSegmentSize}, nil
+}
+
+// Series returns the state of all the series in the CandleStore.
+func (s *CandleStore) Series() ([]SeriesInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var infos []SeriesInfo
+	err := filepath.Walk(s.dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || fi.Name() != "meta.json" {
+			return err
+		}
+		info, err := loadSeriesInfo(filepath.Dir(path), "")
+		if err != nil {
+			return err
+		}
+		infos = append(infos, info)
+		return nil
+	})
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos, err
+}
+
+// Candles.
+
+// AppendCandles stores the candles of the currency and interval.
+func (s *CandleStore) AppendCandles(currency, interval string, cResp []CandlesResponse) error {
+	records, err := candlesRecords(cResp)
+	if err != nil {
+		return err
+	}
+	return s.append(candlesSeries(currency, interval), interval, records)
+}
+
+// QueryCandles returns the stored candles of the currency and interval between start and end, both inclusive.
+// Zero start or end means no bound.
+func (s *CandleStore) QueryCandles(currency, interval string, start, end time.Time) ([]CandlesResponse, error) {
+	records, err := s.query(candlesSeries(currency, interval), start, end)
+	if err != nil {
+		return nil, err
+	}
+	cResp := make([]CandlesResponse, 0, len(records))
+	for _, r := range records {
+		var c CandlesResponse
+		if err := json.Unmarshal(r.Raw, &c); err != nil {
+			return nil, err
+		}
+		cResp = append(cResp, c)
+	}
+	return cResp, nil
+}
+
+// SyncCandles fetches from the server only the candles newer than the last synced one, or from start
+// if the series is empty, stores them and returns the number of candles fetched.
+// The candle of the interval still in progress is not stored, and is fetched by a later sync once closed.
+func (s *CandleStore) SyncCandles(c *Connecter, currency, interval string, start time.Time) (int, error) {
+	return s.sync(candlesSeries(currency, interval), interval, start, func(start, end time.Time) ([]storeRecord, error) {
+		cResp, err := c.GetCandles(CandlesRequest{Interval: interval, Currency: currency, Start: start, End: end})
+		if err != nil {
+			return nil, err
+		}
+		return candlesRecords(cResp)
+	})
+}
+
+// BackfillCandles detects the gaps in the stored candles of the currency and interval, fetches the missing
+// candles from the server, stores them and returns the number of candles fetched.
+func (s *CandleStore) BackfillCandles(c *Connecter, currency, interval string) (int, error) {
+	return s.backfill(candlesSeries(currency, interval), interval, func(start, end time.Time) ([]storeRecord, error) {
+		cResp, err := c.GetCandles(CandlesRequest{Interval: interval, Currency: currency, Start: start, End: end})
+		if err != nil {
+			return nil, err
+		}
+		return candlesRecords(cResp)
+	})
+}
+
+// Exchange Candles.
+
+// AppendExchangeCandles stores the exchange candles of the exchange, market and interval.
+func (s *CandleStore) AppendExchangeCandles(exchange, market, interval string, ecResp []ExchangeCandlesResponse) error {
+	records, err := exchangeCandlesRecords(ecResp)
+	if err != nil {
+		return err
+	}
+	return s.append(exchangeCandlesSeries(exchange, market, interval), interval, records)
+}
+
+// QueryExchangeCandles returns the stored exchange candles of the exchange, market and interval
+// between start and end, both inclusive. Zero start or end means no bound.
+func (s *CandleStore) QueryExchangeCandles(exchange, market, interval string, start, end time.Time) ([]ExchangeCandlesResponse, error) {
+	records, err := s.query(exchangeCandlesSeries(exchange, market, interval), start, end)
+	if err != nil {
+		return nil, err
+	}
+	ecResp := make([]ExchangeCandlesResponse, 0, len(records))
+	for _, r := range records {
+		var ec ExchangeCandlesResponse
+		if err := json.Unmarshal(r.Raw, &ec); err != nil {
+			return nil, err
+		}
+		ecResp = append(ecResp, ec)
+	}
+	return ecResp, nil
+}
+
+// SyncExchangeCandles fetches from the server only the exchange candles newer than the last synced one,
+// or from start if the series is empty, stores them and returns the number of candles fetched.
+// The candle of the interval still in progress is not stored, and is fetched by a later sync once closed.
+func (s *CandleStore) SyncExchangeCandles(c *Connecter, exchange, market, interval string, start time.Time) (int, error) {
+	return s.sync(exchangeCandlesSeries(exchange, market, interval), interval, start, func(start, end time.Time) ([]storeRecord, error) {
+		ecResp, err := c.GetExchangeCandles(ExchangeCandlesRequest{Interval: interval, Exchange: exchange, Market: market, Start: start, End: end})
+		if err != nil {
+			return nil, err
+		}
+		return exchangeCandlesRecords(ecResp)
+	})
+}
+
+// BackfillExchangeCandles detects the gaps in the stored exchange candles, fetches the missing
+// candles from the server, stores them and returns the number of candles fetched.
+func (s *CandleStore) BackfillExchangeCandles(c *Connecter, exchange, market, interval string) (int, error) {
+	return s.backfill(exchangeCandlesSeries(exchange, market, interval), interval, func(start, end time.Time) ([]storeRecord, error) {
+		ecResp, err := c.GetExchangeCandles(ExchangeCandlesRequest{Interval: interval, Exchange: exchange, Market: market, Start: start, End: end})
+		if err != nil {
+			return nil, err
+		}
+		return exchangeCandlesRecords(ecResp)
+	})
+}
+
+// Markets Candles.
+
+// AppendMarketsCandles stores the markets candles of the base, quote and interval.
+func (s *CandleStore) AppendMarketsCandles(base, quote, interval string, mcResp []MarketsCandlesResponse) error {
+	records, err := marketsCandlesRecords(mcResp)
+	if err != nil {
+		return err
+	}
+	return s.append(marketsCandlesSeries(base, quote, interval), interval, records)
+}
+
+// QueryMarketsCandles returns the stored markets candles of the base, quote and interval
+// between start and end, both inclusive. Zero start or end means no bound.
+func (s *CandleStore) QueryMarketsCandles(base, quote, interval string, start, end time.Time) ([]MarketsCandlesResponse, error) {
+	records, err := s.query(marketsCandlesSeries(base, quote, interval), start, end)
+	if err != nil {
+		return nil, err
+	}
+	mcResp := make([]MarketsCandlesResponse, 0, len(records))
+	for _, r := range records {
+		var mc MarketsCandlesResponse
+		if err := json.Unmarshal(r.Raw, &mc); err != nil {
+			return nil, err
+		}
+		mcResp = append(mcResp, mc)
+	}
+	return mcResp, nil
+}
+
+// SyncMarketsCandles fetches from the server only the markets candles newer than the last synced one,
+// or from start if the series is empty, stores them and returns the number of candles fetched.
+// The candle of the interval still in progress is not stored, and is fetched by a later sync once closed.
+func (s *CandleStore) SyncMarketsCandles(c *Connecter, base, quote, interval string, start time.Time) (int, error) {
+	return s.sync(marketsCandlesSeries(base, quote, interval), interval, start, func(start, end time.Time) ([]storeRecord, error) {
+		mcResp, err := c.GetMarketsCandles(MarketsCandlesRequest{Interval: interval, Base: base, Quote: quote, Start: start, End: end})
+		if err != nil {
+			return nil, err
+		}
+		return marketsCandlesRecords(mcResp)
+	})
+}
+
+// BackfillMarketsCandles detects the gaps in the stored markets candles, fetches the missing
+// candles from the server, stores them and returns the number of candles fetched.
+func (s *CandleStore) BackfillMarketsCandles(c *Connecter, base, quote, interval string) (int, error) {
+	return s.backfill(marketsCandlesSeries(base, quote, interval), interval, func(start, end time.Time) ([]storeRecord, error) {
+		mcResp, err := c.GetMarketsCandles(MarketsCandlesRequest{Interval: interval, Base: base, Quote: quote, Start: start, End: end})
+		if err != nil {
+			return nil, err
+		}
+		return marketsCandlesRecords(mcResp)
+	})
+}
+
+// Helper methods
+
+// candlesSeries returns the series name of the candles.
+func candlesSeries(currency, interval string) string {
+	return strings.Join([]string{"candles", strings.ToUpper(currency), interval}, "/")
+}
+
+// exchangeCandlesSeries returns the series name of the exchange candles.
+func exchangeCandlesSeries(exchange, market, interval string) string {
+	return strings.Join([]string{"exchange_candles", strings.ToLower(exchange), market, interval}, "/")
+}
+
+// marketsCandlesSeries returns the series name of the markets candles.
+func marketsCandlesSeries(base, quote, interval string) string {
+	return strings.Join([]string{"markets_candles", strings.ToUpper(base), strings.ToUpper(quote), interval}, "/")
+}
+
+// candlesRecords returns the store records of the candles.
+func candlesRecords(cResp []CandlesResponse) ([]storeRecord, error) {
+	records := make([]storeRecord, 0, len(cResp))
+	for _, c := range cResp {
+		raw, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, storeRecord{Timestamp: c.Timestamp, Raw: raw})
+	}
+	return records, nil
+}
+
+// exchangeCandlesRecords returns the store records of the exchange candles.
+func exchangeCandlesRecords(ecResp []ExchangeCandlesResponse) ([]storeRecord, error) {
+	records := make([]storeRecord, 0, len(ecResp))
+	for _, c := range ecResp {
+		raw, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, storeRecord{Timestamp: c.Timestamp, Raw: raw})
+	}
+	return records, nil
+}
+
+// marketsCandlesRecords returns the store records of the markets candles.
+func marketsCandlesRecords(mcResp []MarketsCandlesResponse) ([]storeRecord, error) {
+	records := make([]storeRecord, 0, len(mcResp))
+	for _, c := range mcResp {
+		raw, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, storeRecord{Timestamp: c.Timestamp, Raw: raw})
+	}
+	return records, nil
+}
+
+// seriesDir returns the directory of the series, after checking the series name can not escape the store directory.
+func (s *CandleStore) seriesDir(name string) (string, error) {
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `\:`) {
+			return "", fmt.Errorf("invalid series %v", name)
+		}
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(name)), nil
+}
+
+// append appends the records to the segment files of the series and updates the series state.
+func (s *CandleStore) append(name, interval string, records []storeRecord) error {
+	if _, err := intervalDuration(interval); err != nil {
+		return err
+	}
+	dir, err := s.seriesDir(name)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	info, err := loadSeriesInfo(dir, name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	info.Interval = interval
+
+	// Ranges are only kept if every segment has one.
+	ranged := len(info.Ranges) == info.Segments
+	for len(records) > 0 {
+		if info.Segments == 0 || info.SegmentCount >= s.segmentSize {
+			info.Segments++
+			info.SegmentCount = 0
+			info.SegmentBytes = 0
+			if ranged {
+				info.Ranges = append(info.Ranges, SegmentRange{})
+			}
+		}
+		n := s.segmentSize - info.SegmentCount
+		if n > len(records) {
+			n = len(records)
+		}
+		if err := appendSegment(filepath.Join(dir, segmentName(info.Segments)), records[:n]); err != nil {
+			return err
+		}
+		for _, r := range records[:n] {
+			info.SegmentBytes += int64(len(r.Raw)) + 1
+			if r.Timestamp.After(info.LastTimestamp) {
+				info.LastTimestamp = r.Timestamp
+			}
+			if ranged {
+				rg := &info.Ranges[info.Segments-1]
+				if rg.First.IsZero() || r.Timestamp.Before(rg.First) {
+					rg.First = r.Timestamp
+				}
+				if r.Timestamp.After(rg.Last) {
+					rg.Last = r.Timestamp
+				}
+			}
+		}
+		info.SegmentCount += n
+		info.Count += n
+		records = records[n:]
+	}
+	return writeSeriesInfo(filepath.Join(dir, "meta.json"), info)
+}
+
+// query returns the records of the series between start and end, sorted by timestamp, reading only the segments
+// whose range overlaps start and end. For records with the same timestamp, the last appended one is returned.
+func (s *CandleStore) query(name string, start, end time.Time) ([]storeRecord, error) {
+	dir, err := s.seriesDir(name)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, err := loadSeriesInfo(dir, name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[time.Time]storeRecord)
+	for i := 1; i <= info.Segments; i++ {
+		if len(info.Ranges) == info.Segments {
+			rg := info.Ranges[i-1]
+			if (!start.IsZero() && rg.Last.Before(start)) || (!end.IsZero() && rg.First.After(end)) {
+				continue
+			}
+		}
+		records, err := readSegment(filepath.Join(dir, segmentName(i)))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			if (!start.IsZero() && r.Timestamp.Before(start)) || (!end.IsZero() && r.Timestamp.After(end)) {
+				continue
+			}
+			latest[r.Timestamp] = r
+		}
+	}
+	records := make([]storeRecord, 0, len(latest))
+	for _, r := range latest {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+// sync fetches the records newer than the last synced one, or from start if the series is empty, and appends them.
+func (s *CandleStore) sync(name, interval string, start time.Time, fetch func(start, end time.Time) ([]storeRecord, error)) (int, error) {
+	step, err := intervalDuration(interval)
+	if err != nil {
+		return 0, err
+	}
+	dir, err := s.seriesDir(name)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	info, err := loadSeriesInfo(dir, name)
+	s.mu.Unlock()
+	if err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	if !info.LastTimestamp.IsZero() {
+		start = info.LastTimestamp.Add(step)
+	}
+	if start.IsZero() {
+		return 0, errors.New("start is required for an empty series")
+	}
+	now := time.Now().UTC()
+	if !start.Before(now) {
+		return 0, nil
+	}
+
+	records, err := fetch(start, now)
+	if err != nil {
+		return 0, err
+	}
+	var fresh []storeRecord
+	for _, r := range records {
+		// The record of the interval still in progress is left for the next sync, once the interval is closed.
+		if r.Timestamp.After(info.LastTimestamp) && !r.Timestamp.Add(step).After(now) {
+			fresh = append(fresh, r)
+		}
+	}
+	if len(fresh) == 0 {
+		return 0, nil
+	}
+	return len(fresh), s.append(name, interval, fresh)
+}
+
+// backfill fetches the records missing between the stored records and appends them.
+func (s *CandleStore) backfill(name, interval string, fetch func(start, end time.Time) ([]storeRecord, error)) (int, error) {
+	step, err := intervalDuration(interval)
+	if err != nil {
+		return 0, err
+	}
+	stored, err := s.query(name, time.Time{}, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+	have := make(map[time.Time]bool, len(stored))
+	for _, r := range stored {
+		have[r.Timestamp] = true
+	}
+
+	var fetched int
+	for i := 1; i < len(stored); i++ {
+		prev, next := stored[i-1].Timestamp, stored[i].Timestamp
+		if next.Sub(prev) <= step {
+			continue
+		}
+		records, err := fetch(prev.Add(step), next.Add(-step))
+		if err != nil {
+			return fetched, err
+		}
+		var missing []storeRecord
+		for _, r := range records {
+			if r.Timestamp.After(prev) && r.Timestamp.Before(next) && !have[r.Timestamp] {
+				missing = append(missing, r)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		if err := s.append(name, interval, missing); err != nil {
+			return fetched, err
+		}
+		fetched += len(missing)
+	}
+	return fetched, nil
+}
+
+// segmentName returns the file name of the nth segment.
+func segmentName(n int) string {
+	return fmt.Sprintf("%06d.seg", n)
+}
+
+// appendSegment appends the records as json lines to the segment file.
+func appendSegment(name string, records []storeRecord) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, r := range records {
+		w.Write(r.Raw)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readSegment reads the records of the segment file. A partially written last line is ignored.
+func readSegment(name string) ([]storeRecord, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []storeRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var r storeRecord
+		if err := json.Unmarshal(line, &r); err != nil {
+			continue
+		}
+		r.Raw = append(json.RawMessage{}, line...)
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// loadSeriesInfo reads the state of the series in the directory and recovers the records written to the segment
+// files after the last state write: the last segment is read again if its size differs from the state,
+// along with the segments not in the state yet. It returns a not exist error if the series has no files.
+func loadSeriesInfo(dir, name string) (SeriesInfo, error) {
+	info, err := readSeriesInfo(filepath.Join(dir, "meta.json"))
+	missing := os.IsNotExist(err)
+	if missing {
+		info, err = SeriesInfo{Name: name}, nil
+	}
+	if err != nil {
+		return info, err
+	}
+	n := info.Segments
+	if n == 0 {
+		n = 1
+	}
+	for ; ; n++ {
+		seg := filepath.Join(dir, segmentName(n))
+		fi, err := os.Stat(seg)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return info, err
+		}
+		missing = false
+		if n == info.Segments && fi.Size() == info.SegmentBytes {
+			continue
+		}
+		if err := recoverSegment(&info, n, seg); err != nil {
+			return info, err
+		}
+	}
+	if missing {
+		return info, os.ErrNotExist
+	}
+	return info, nil
+}
+
+// recoverSegment updates the series state with the records of the nth segment file, which is either the last
+// segment of the state or the next one. A partially written last line is removed, so that the next append
+// starts on a new line.
+func recoverSegment(info *SeriesInfo, n int, name string) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if i := bytes.LastIndexByte(data, '\n'); i+1 < len(data) {
+		data = data[:i+1]
+		if err := os.Truncate(name, int64(len(data))); err != nil {
+			return err
+		}
+	}
+	records, err := readSegment(name)
+	if err != nil {
+		return err
+	}
+
+	ranged := len(info.Ranges) == info.Segments
+	if n > info.Segments {
+		info.Segments = n
+		info.SegmentCount = 0
+		if ranged {
+			info.Ranges = append(info.Ranges, SegmentRange{})
+		}
+	}
+	lines := bytes.Count(data, []byte{'\n'})
+	info.Count += lines - info.SegmentCount
+	info.SegmentCount = lines
+	info.SegmentBytes = int64(len(data))
+	var rg SegmentRange
+	for _, r := range records {
+		if r.Timestamp.After(info.LastTimestamp) {
+			info.LastTimestamp = r.Timestamp
+		}
+		if rg.First.IsZero() || r.Timestamp.Before(rg.First) {
+			rg.First = r.Timestamp
+		}
+		if r.Timestamp.After(rg.Last) {
+			rg.Last = r.Timestamp
+		}
+	}
+	if ranged {
+		info.Ranges[n-1] = rg
+	}
+	return nil
+}
+
+// readSeriesInfo reads the series state file.
+func readSeriesInfo(name string) (SeriesInfo, error) {
+	var info SeriesInfo
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(data, &info)
+	return info, err
+}
+
+// writeSeriesInfo atomically replaces the series state file.
+func writeSeriesInfo(name string, info SeriesInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(name, data)
+}
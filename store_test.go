@@ -0,0 +1,178 @@
+package gonomics
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCandleStore tests incremental sync, gap backfill and range queries of the candle store.
+func TestCandleStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonomics_candle_store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	startTime, _ := time.Parse(time.RFC3339, "2021-01-01T00:00:00Z")
+	var requests []string
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		q := req.URL.Query()
+		requests = append(requests, q.Get("start"))
+		start, _ := time.Parse(time.RFC3339, q.Get("start"))
+		end, _ := time.Parse(time.RFC3339, q.Get("end"))
+		var candles []string
+		for ts := start; !ts.After(end) && ts.Before(startTime.Add(5*24*time.Hour)); ts = ts.Add(24 * time.Hour) {
+			candles = append(candles, fmt.Sprintf(`{"timestamp":"%v","close":"%v"}`, ts.Format(time.RFC3339), ts.Day()))
+		}
+		return newTestResponse(req, http.StatusOK, "["+strings.Join(candles, ",")+"]"), nil
+	})
+
+	s, err := OpenCandleStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stored candles with a gap on day 2.
+	err = s.AppendCandles("BTC", "1d", []CandlesResponse{
+		{Timestamp: startTime, Close: 1},
+		{Timestamp: startTime.Add(2 * 24 * time.Hour), Close: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.BackfillCandles(c, "BTC", "1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("Something is wrong here, expected 1 backfilled candle, got %v.", n)
+	}
+
+	// Sync fetches only after the last synced candle.
+	requests = nil
+	n, err = s.SyncCandles(c, "BTC", "1d", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Something is wrong here, expected 2 synced candles, got %v.", n)
+	}
+	if len(requests) != 1 || requests[0] != startTime.Add(3*24*time.Hour).Format(time.RFC3339) {
+		t.Errorf("Something is wrong here, unexpected sync requests %v.", requests)
+	}
+
+	// Appending the same candle again does not duplicate it.
+	if err := s.AppendCandles("BTC", "1d", []CandlesResponse{{Timestamp: startTime, Close: 10}}); err != nil {
+		t.Fatal(err)
+	}
+	cResp, err := s.QueryCandles("BTC", "1d", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cResp) != 5 || cResp[0].Close != 10 || cResp[4].Close != 5 {
+		t.Errorf("Something is wrong here, unexpected stored candles %v.", cResp)
+	}
+	cResp, err = s.QueryCandles("BTC", "1d", startTime.Add(24*time.Hour), startTime.Add(2*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cResp) != 2 || cResp[0].Close != 2 || cResp[1].Close != 3 {
+		t.Errorf("Something is wrong here, unexpected range query result %v.", cResp)
+	}
+
+	infos, err := s.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Name != "candles/BTC/1d" || !infos[0].LastTimestamp.Equal(startTime.Add(4*24*time.Hour)) {
+		t.Errorf("Something is wrong here, unexpected series %v.", infos)
+	}
+
+	// Range queries skip the segments out of the range.
+	s.segmentSize = 2
+	var eResp []CandlesResponse
+	for i := 0; i < 4; i++ {
+		eResp = append(eResp, CandlesResponse{Timestamp: startTime.Add(time.Duration(i) * 24 * time.Hour), Close: float64(i)})
+	}
+	if err := s.AppendCandles("ETH", "1d", eResp); err != nil {
+		t.Fatal(err)
+	}
+	first := filepath.Join(dir, "candles", "ETH", "1d", segmentName(1))
+	if err := os.Remove(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(first, 0755); err != nil {
+		t.Fatal(err)
+	}
+	cResp, err = s.QueryCandles("ETH", "1d", startTime.Add(2*24*time.Hour), time.Time{})
+	if err != nil || len(cResp) != 2 || cResp[0].Close != 2 {
+		t.Errorf("Something is wrong here, unexpected range query result %v, %v.", cResp, err)
+	}
+	if _, err := s.QueryCandles("ETH", "1d", time.Time{}, time.Time{}); err == nil {
+		t.Error("Something is wrong here, expected unreadable first segment to be read.")
+	}
+
+	// Records written to the segments after the last state write are recovered, and a partial line is removed.
+	var lResp []CandlesResponse
+	for i := 0; i < 3; i++ {
+		lResp = append(lResp, CandlesResponse{Timestamp: startTime.Add(time.Duration(i) * 24 * time.Hour), Close: float64(i)})
+	}
+	if err := s.AppendCandles("LTC", "1d", lResp); err != nil {
+		t.Fatal(err)
+	}
+	line := func(day int) string {
+		return fmt.Sprintf(`{"timestamp":"%v","close":"%v"}`+"\n", startTime.Add(time.Duration(day)*24*time.Hour).Format(time.RFC3339), day)
+	}
+	lDir := filepath.Join(dir, "candles", "LTC", "1d")
+	f, err := os.OpenFile(filepath.Join(lDir, segmentName(2)), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(line(3))
+	f.Close()
+	if err := ioutil.WriteFile(filepath.Join(lDir, segmentName(3)), []byte(line(4)+`{"timestamp":"2021`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	infos, err = s.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 3 || infos[2].Name != "candles/LTC/1d" || infos[2].Count != 5 || infos[2].Segments != 3 ||
+		!infos[2].LastTimestamp.Equal(startTime.Add(4*24*time.Hour)) {
+		t.Errorf("Something is wrong here, unexpected recovered series %v.", infos)
+	}
+	if err := s.AppendCandles("LTC", "1d", []CandlesResponse{{Timestamp: startTime.Add(5 * 24 * time.Hour), Close: 5}}); err != nil {
+		t.Fatal(err)
+	}
+	cResp, err = s.QueryCandles("LTC", "1d", startTime.Add(3*24*time.Hour), time.Time{})
+	if err != nil || len(cResp) != 3 || cResp[0].Close != 3 || cResp[2].Close != 5 {
+		t.Errorf("Something is wrong here, unexpected recovered candles %v, %v.", cResp, err)
+	}
+
+	// Sync does not store the candle of the interval still in progress.
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		start, _ := time.Parse(time.RFC3339, req.URL.Query().Get("start"))
+		var candles []string
+		for ts := start; !ts.After(today); ts = ts.Add(24 * time.Hour) {
+			candles = append(candles, fmt.Sprintf(`{"timestamp":"%v","close":"1"}`, ts.Format(time.RFC3339)))
+		}
+		return newTestResponse(req, http.StatusOK, "["+strings.Join(candles, ",")+"]"), nil
+	})
+	if n, err := s.SyncCandles(c, "ADA", "1d", today.Add(-2*24*time.Hour)); err != nil || n != 2 {
+		t.Errorf("Something is wrong here, expected 2 closed candles synced, got %v, %v.", n, err)
+	}
+
+	// Empty series needs a start.
+	if _, err := s.SyncCandles(c, "XRP", "1d", time.Time{}); err == nil {
+		t.Error("Something is wrong here, expected error for empty series without start.")
+	}
+}
@@ -0,0 +1,165 @@
+package gonomics
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Supply Analytics.
+
+// year is the duration used to annualize rates.
+const year = 365 * 24 * time.Hour
+
+// SupplyAnalysis represents inflation and emission analytics derived from the currency supply history.
+// Pct fields are fractions (0.05 is 5%).
+type SupplyAnalysis struct {
+	Start       time.Time
+	End         time.Time
+	StartSupply float64
+	EndSupply   float64
+
+	// MaxSupply is 0 if the currency has no max supply.
+	MaxSupply float64
+
+	// Fraction of MaxSupply already in circulation, 0 if there is no MaxSupply.
+	MinedPct float64
+
+	// Compounded yearly growth of the circulating supply between Start and End.
+	AnnualizedInflationPct float64
+
+	// Average supply emitted per day between Start and End.
+	EmissionPerDay float64
+
+	// Date when MaxSupply is reached by extrapolating EmissionPerDay linearly.
+	// Zero if there is no MaxSupply, no emission, or if it is more than about 292 years away, the time.Duration range.
+	ProjectedMaxSupplyDate time.Time
+
+	// Emission schedule curve, one point per supply history point.
+	Emission []SupplyEmission
+}
+
+// SupplyEmission represents one point of the emission schedule curve, Included in SupplyAnalysis.
+type SupplyEmission struct {
+	Timestamp time.Time
+	Available float64
+
+	// Supply emitted since the previous point.
+	Emitted float64
+
+	// Emission since the previous point as a yearly growth of the supply.
+	AnnualizedInflationPct float64
+
+	// Fraction of MaxSupply in circulation at this point, 0 if there is no MaxSupply.
+	MinedPct float64
+}
+
+// SupplyValuation represents the market cap and fully diluted valuation at a time.
+type SupplyValuation struct {
+	Timestamp             time.Time
+	Price                 float64
+	CirculatingSupply     float64
+	MarketCap             float64
+	FullyDilutedValuation float64
+}
+
+// AnalyzeSupply computes the inflation and emission analytics of the currency supply history.
+// maxSupply is used as max supply, like CurrenciesTickerResponse.MaxSupply,
+// if it is 0 the Max of the latest supply history point is used.
+func AnalyzeSupply(cshResp []CurrenciesSupplyHistoryResponse, maxSupply float64) (SupplyAnalysis, error) {
+	points := sortedSupply(cshResp)
+	if len(points) < 2 {
+		return SupplyAnalysis{}, errors.New("at least 2 supply history points are required")
+	}
+	first, last := points[0], points[len(points)-1]
+	if maxSupply == 0 {
+		maxSupply = last.Max
+	}
+
+	sa := SupplyAnalysis{
+		Start:       first.Timestamp,
+		End:         last.Timestamp,
+		StartSupply: first.Available,
+		EndSupply:   last.Available,
+		MaxSupply:   maxSupply,
+	}
+	if maxSupply > 0 {
+		sa.MinedPct = last.Available / maxSupply
+	}
+	sa.AnnualizedInflationPct = annualizedGrowth(first.Available, last.Available, last.Timestamp.Sub(first.Timestamp))
+	if days := last.Timestamp.Sub(first.Timestamp).Hours() / 24; days > 0 {
+		sa.EmissionPerDay = (last.Available - first.Available) / days
+	}
+	if maxSupply > last.Available && sa.EmissionPerDay > 0 {
+		if d := (maxSupply - last.Available) / sa.EmissionPerDay * float64(24*time.Hour); d < math.MaxInt64 {
+			sa.ProjectedMaxSupplyDate = last.Timestamp.Add(time.Duration(d))
+		}
+	}
+
+	for i, p := range points {
+		se := SupplyEmission{Timestamp: p.Timestamp, Available: p.Available}
+		if i > 0 {
+			prev := points[i-1]
+			se.Emitted = p.Available - prev.Available
+			se.AnnualizedInflationPct = annualizedGrowth(prev.Available, p.Available, p.Timestamp.Sub(prev.Timestamp))
+		}
+		if maxSupply > 0 {
+			se.MinedPct = p.Available / maxSupply
+		}
+		sa.Emission = append(sa.Emission, se)
+	}
+	return sa, nil
+}
+
+// FullyDilutedValuations combines the supply history with the candle prices and returns the market cap
+// and fully diluted valuation at each candle timestamp, using the latest supply point at or before it.
+// maxSupply is used for the fully diluted valuation, if it is 0 the Max of the supply point is used,
+// and if that is 0 too, the circulating supply is used.
+func FullyDilutedValuations(cshResp []CurrenciesSupplyHistoryResponse, cResp []CandlesResponse, maxSupply float64) []SupplyValuation {
+	points := sortedSupply(cshResp)
+	var svs []SupplyValuation
+	for _, c := range cResp {
+		i := sort.Search(len(points), func(i int) bool {
+			return points[i].Timestamp.After(c.Timestamp)
+		}) - 1
+		if i < 0 {
+			continue
+		}
+		p := points[i]
+		dilutedSupply := maxSupply
+		if dilutedSupply == 0 {
+			dilutedSupply = p.Max
+		}
+		if dilutedSupply == 0 {
+			dilutedSupply = p.Available
+		}
+		svs = append(svs, SupplyValuation{
+			Timestamp:             c.Timestamp,
+			Price:                 c.Close,
+			CirculatingSupply:     p.Available,
+			MarketCap:             c.Close * p.Available,
+			FullyDilutedValuation: c.Close * dilutedSupply,
+		})
+	}
+	return svs
+}
+
+// Helper methods
+
+// sortedSupply returns a copy of the supply history sorted by timestamp.
+func sortedSupply(cshResp []CurrenciesSupplyHistoryResponse) []CurrenciesSupplyHistoryResponse {
+	points := append([]CurrenciesSupplyHistoryResponse{}, cshResp...)
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Timestamp.Before(points[j].Timestamp)
+	})
+	return points
+}
+
+// annualizedGrowth returns the compounded yearly growth from one value to another over the duration.
+func annualizedGrowth(from, to float64, d time.Duration) float64 {
+	if from <= 0 || to <= 0 || d <= 0 {
+		return 0
+	}
+	return math.Pow(to/from, float64(year)/float64(d)) - 1
+}
@@ -0,0 +1,49 @@
+package gonomics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestAnalyzeSupply tests supply inflation and emission analytics functions.
+func TestAnalyzeSupply(t *testing.T) {
+	startTime, _ := time.Parse(time.RFC3339, "2020-01-01T00:00:00Z")
+	cshResp := []CurrenciesSupplyHistoryResponse{
+		{Timestamp: startTime.Add(365 * 24 * time.Hour), Available: 110, Max: 200},
+		{Timestamp: startTime, Available: 100, Max: 200},
+	}
+	sa, err := AnalyzeSupply(cshResp, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(sa.AnnualizedInflationPct-0.1) > 1e-9 || sa.MinedPct != 0.55 || sa.MaxSupply != 200 {
+		t.Errorf("Something is wrong here, unexpected supply analysis %v.", sa)
+	}
+	expectedDate := startTime.Add(365 * 24 * time.Hour).Add(9 * 365 * 24 * time.Hour)
+	if d := sa.ProjectedMaxSupplyDate.Sub(expectedDate); d > time.Second || d < -time.Second {
+		t.Errorf("Something is wrong here, unexpected projected max supply date %v.", sa.ProjectedMaxSupplyDate)
+	}
+	if len(sa.Emission) != 2 || sa.Emission[1].Emitted != 10 {
+		t.Errorf("Something is wrong here, unexpected emission schedule %v.", sa.Emission)
+	}
+	// Projection beyond the time.Duration range is left zero.
+	slow := []CurrenciesSupplyHistoryResponse{
+		{Timestamp: startTime, Available: 100, Max: 1000100},
+		{Timestamp: startTime.Add(365 * 24 * time.Hour), Available: 465, Max: 1000100},
+	}
+	if sa, err := AnalyzeSupply(slow, 0); err != nil || !sa.ProjectedMaxSupplyDate.IsZero() {
+		t.Errorf("Something is wrong here, expected zero projected max supply date, got %v, %v.", sa.ProjectedMaxSupplyDate, err)
+	}
+	if _, err := AnalyzeSupply(cshResp[:1], 0); err == nil {
+		t.Error("Something is wrong here, analysis of single point should fail.")
+	}
+
+	svs := FullyDilutedValuations(cshResp, []CandlesResponse{
+		{Timestamp: startTime.Add(-time.Hour), Close: 1},
+		{Timestamp: startTime.Add(time.Hour), Close: 2},
+	}, 0)
+	if len(svs) != 1 || svs[0].MarketCap != 200 || svs[0].FullyDilutedValuation != 400 {
+		t.Errorf("Something is wrong here, unexpected valuations %v.", svs)
+	}
+}
@@ -49,11 +49,13 @@ func (c *Connecter) GetTrades(tReq TradesRequest) ([]TradesResponse, error) {
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if tReq.Exchange == "" {
 		return nil, errors.New("exchange is required")
 	}
 	q.Add("exchange", tReq.Exchange)
+	if err := c.checkCapability(tReq.Exchange, CapabilityTrades); err != nil {
+		return nil, err
+	}
 	if tReq.Market == "" {
 		return nil, errors.New("market is required")
 	}
@@ -65,6 +67,9 @@ func (c *Connecter) GetTrades(tReq TradesRequest) ([]TradesResponse, error) {
 		q.Add("order", tReq.Order)
 	}
 	if !tReq.From.IsZero() {
+		if err := c.checkCapability(tReq.Exchange, CapabilityTradesByTimestamp); err != nil {
+			return nil, err
+		}
 		q.Add("from", tReq.From.Format(time.RFC3339))
 	}
 	if tReq.Format != "" {
@@ -76,7 +81,7 @@ func (c *Connecter) GetTrades(tReq TradesRequest) ([]TradesResponse, error) {
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, tReq)
 	if err != nil {
 		return nil, err
 	}
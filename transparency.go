@@ -0,0 +1,209 @@
+package gonomics
+
+import (
+	"sort"
+	"time"
+)
+
+// Transparency Analysis.
+
+// Volume transparency grades given by nomics, "?" is for the volume which is not graded.
+// Volume of grades A and B is considered as transparent volume.
+var transparencyGrades = []string{"A", "B", "C", "D", "?"}
+
+// GradeShare represents the volume of one transparency grade and its share of the total volume as a fraction.
+type GradeShare struct {
+	Grade    string
+	Volume   float64
+	SharePct float64
+}
+
+// TransparencyDivergence represents the divergence between the reported and the transparent value
+// of volume or market cap of a currency or exchange.
+// DivergencePct is the fraction of the reported value which is not transparent, 1 - Transparent / Reported.
+type TransparencyDivergence struct {
+	ID            string
+	Timestamp     time.Time
+	Reported      float64
+	Transparent   float64
+	DivergencePct float64
+}
+
+// GradeBreakdown represents the volume of each transparency grade at a candle timestamp.
+type GradeBreakdown struct {
+	Timestamp         time.Time
+	Volume            float64
+	TransparentVolume float64
+	Grades            []GradeShare
+}
+
+// GlobalTransparencyShares returns the volume share of each transparency grade of the global-ticker interval.
+func GlobalTransparencyShares(gti GlobalTickerIntervalResponse) []GradeShare {
+	volumes := make(map[string]float64, len(gti.VolumeTransparency))
+	for _, vt := range gti.VolumeTransparency {
+		volumes[vt.Grade] += vt.Volume
+	}
+	return gradeShares(volumes)
+}
+
+// CurrencyTransparencyShares returns the volume share of each transparency grade of the currencies ticker interval.
+func CurrencyTransparencyShares(cti CurrenciesTickerIntervalResponse) []GradeShare {
+	volumes := make(map[string]float64, len(cti.VolumeTransparency))
+	for _, vt := range cti.VolumeTransparency {
+		volumes[vt.Grade] += vt.Volume
+	}
+	return gradeShares(volumes)
+}
+
+// CandleTransparencyShares returns the volume share of each transparency grade of the candle.
+func CandleTransparencyShares(c CandlesResponse) []GradeShare {
+	return gradeShares(candleGradeVolumes(c.VolumeTransparency))
+}
+
+// CurrencyVolumeDivergence flags the currencies whose interval volume diverges from the volume
+// of transparent grades A and B by more than threshold, a fraction like 0.5.
+// interval is one of the currencies ticker intervals, like "1d" or "7d".
+// Currencies ticker must be fetched with IncludeTransparency, currencies without volume transparency are skipped.
+func CurrencyVolumeDivergence(ctResp []CurrenciesTickerResponse, interval string, threshold float64) []TransparencyDivergence {
+	var tds []TransparencyDivergence
+	for _, ct := range ctResp {
+		cti, ok := ct.interval(interval)
+		if !ok || len(cti.VolumeTransparency) == 0 {
+			continue
+		}
+		var transparent float64
+		for _, vt := range cti.VolumeTransparency {
+			if vt.Grade == "A" || vt.Grade == "B" {
+				transparent += vt.Volume
+			}
+		}
+		if td, ok := newTransparencyDivergence(ct.ID, ct.PriceTimestamp, cti.Volume, transparent, threshold); ok {
+			tds = append(tds, td)
+		}
+	}
+	return tds
+}
+
+// CurrencyMarketCapDivergence flags the currencies whose market cap diverges from the transparent
+// market cap by more than threshold, a fraction like 0.5. Currencies without transparent market cap are skipped.
+func CurrencyMarketCapDivergence(ctResp []CurrenciesTickerResponse, threshold float64) []TransparencyDivergence {
+	var tds []TransparencyDivergence
+	for _, ct := range ctResp {
+		if ct.TransparentMarketCap == 0 {
+			continue
+		}
+		if td, ok := newTransparencyDivergence(ct.ID, ct.PriceTimestamp, ct.MarketCap, ct.TransparentMarketCap, threshold); ok {
+			tds = append(tds, td)
+		}
+	}
+	return tds
+}
+
+// ExchangeVolumeDivergence flags the points of the exchange volume history whose volume diverges from
+// the transparent volume by more than threshold, a fraction like 0.5.
+// Volume history must be fetched with IncludeTransparency, points without transparent volume are skipped.
+func ExchangeVolumeDivergence(exchange string, evhResp []ExchangesVolumeHistoryResponse, threshold float64) []TransparencyDivergence {
+	var tds []TransparencyDivergence
+	for _, evh := range evhResp {
+		if evh.TransparentVolume == 0 {
+			continue
+		}
+		if td, ok := newTransparencyDivergence(exchange, evh.Timestamp, evh.Volume, evh.TransparentVolume, threshold); ok {
+			tds = append(tds, td)
+		}
+	}
+	return tds
+}
+
+// CandlesGradeBreakdown returns the transparency grade breakdown of the volume at each candle timestamp.
+func CandlesGradeBreakdown(cResp []CandlesResponse) []GradeBreakdown {
+	gbs := make([]GradeBreakdown, 0, len(cResp))
+	for _, c := range cResp {
+		gbs = append(gbs, GradeBreakdown{
+			Timestamp:         c.Timestamp,
+			Volume:            c.Volume,
+			TransparentVolume: c.TransparentVolume,
+			Grades:            CandleTransparencyShares(c),
+		})
+	}
+	return gbs
+}
+
+// Helper methods
+
+// interval returns the currencies ticker interval response of the interval name.
+func (ct CurrenciesTickerResponse) interval(interval string) (CurrenciesTickerIntervalResponse, bool) {
+	switch interval {
+	case "1h":
+		return ct.OneH, true
+	case "1d":
+		return ct.OneD, true
+	case "7d":
+		return ct.SevenD, true
+	case "30d":
+		return ct.Three0D, true
+	case "365d":
+		return ct.Three65D, true
+	case "ytd":
+		return ct.Ytd, true
+	}
+	return CurrenciesTickerIntervalResponse{}, false
+}
+
+// candleGradeVolumes returns the candle volume keyed by transparency grade.
+func candleGradeVolumes(cvt CandlesVolumeTransparencyResponse) map[string]float64 {
+	return map[string]float64{
+		"A": cvt.A,
+		"B": cvt.B,
+		"C": cvt.C,
+		"D": cvt.D,
+		"?": cvt.Others,
+	}
+}
+
+// gradeShares returns the share of each grade volume in the total volume, in the order of transparencyGrades.
+// Grades not known to transparencyGrades are appended at the end.
+func gradeShares(volumes map[string]float64) []GradeShare {
+	var total float64
+	for _, v := range volumes {
+		total += v
+	}
+	grades := append([]string{}, transparencyGrades...)
+	known := make(map[string]bool, len(transparencyGrades))
+	for _, g := range transparencyGrades {
+		known[g] = true
+	}
+	var unknown []string
+	for g := range volumes {
+		if !known[g] {
+			unknown = append(unknown, g)
+		}
+	}
+	sort.Strings(unknown)
+	grades = append(grades, unknown...)
+
+	gss := make([]GradeShare, 0, len(grades))
+	for _, g := range grades {
+		gs := GradeShare{Grade: g, Volume: volumes[g]}
+		if total != 0 {
+			gs.SharePct = gs.Volume / total
+		}
+		gss = append(gss, gs)
+	}
+	return gss
+}
+
+// newTransparencyDivergence returns the divergence, if the reported value diverges from the transparent one by more than threshold.
+func newTransparencyDivergence(id string, ts time.Time, reported, transparent, threshold float64) (TransparencyDivergence, bool) {
+	if reported <= 0 {
+		return TransparencyDivergence{}, false
+	}
+	td := TransparencyDivergence{
+		ID:            id,
+		Timestamp:     ts,
+		Reported:      reported,
+		Transparent:   transparent,
+		DivergencePct: 1 - transparent/reported,
+	}
+	return td, td.DivergencePct > threshold
+}
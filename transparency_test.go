@@ -0,0 +1,49 @@
+package gonomics
+
+import (
+	"testing"
+)
+
+// TestTransparency tests transparency grade share and divergence functions.
+func TestTransparency(t *testing.T) {
+	gss := GlobalTransparencyShares(GlobalTickerIntervalResponse{
+		VolumeTransparency: []GlobalTickerIntervalVolumeTransparencyResponse{
+			{Grade: "A", Volume: 60},
+			{Grade: "C", Volume: 30},
+			{Grade: "?", Volume: 10},
+		},
+	})
+	if len(gss) != 5 || gss[0].SharePct != 0.6 || gss[1].SharePct != 0 || gss[4].Grade != "?" {
+		t.Errorf("Something is wrong here, unexpected grade shares %v.", gss)
+	}
+
+	ctResp := []CurrenciesTickerResponse{
+		{ID: "BTC", MarketCap: 100, TransparentMarketCap: 90, OneD: CurrenciesTickerIntervalResponse{
+			Volume: 100,
+			VolumeTransparency: []CurrenciesTickerIntervalVolumeTransparencyResponse{
+				{Grade: "A", Volume: 20}, {Grade: "D", Volume: 80},
+			},
+		}},
+		{ID: "ETH", MarketCap: 100, TransparentMarketCap: 30},
+		{ID: "XRP", MarketCap: 100, OneD: CurrenciesTickerIntervalResponse{Volume: 100}},
+	}
+	tds := CurrencyVolumeDivergence(ctResp, "1d", 0.5)
+	if len(tds) != 1 || tds[0].ID != "BTC" || tds[0].DivergencePct != 0.8 {
+		t.Errorf("Something is wrong here, unexpected volume divergence %v.", tds)
+	}
+	tds = CurrencyMarketCapDivergence(ctResp, 0.5)
+	if len(tds) != 1 || tds[0].ID != "ETH" {
+		t.Errorf("Something is wrong here, unexpected market cap divergence %v.", tds)
+	}
+	tds = ExchangeVolumeDivergence("binance", []ExchangesVolumeHistoryResponse{{Volume: 10, TransparentVolume: 10}, {Volume: 10}}, 0)
+	if len(tds) != 0 {
+		t.Errorf("Something is wrong here, unexpected exchange volume divergence %v.", tds)
+	}
+
+	gbs := CandlesGradeBreakdown([]CandlesResponse{
+		{Volume: 10, TransparentVolume: 5, VolumeTransparency: CandlesVolumeTransparencyResponse{A: 5, D: 5}},
+	})
+	if len(gbs) != 1 || gbs[0].Grades[0].SharePct != 0.5 || gbs[0].Grades[3].SharePct != 0.5 {
+		t.Errorf("Something is wrong here, unexpected grade breakdown %v.", gbs)
+	}
+}
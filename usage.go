@@ -0,0 +1,275 @@
+package gonomics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Usage Tracking.
+
+// ErrBudgetExceeded is returned, without calling the server, when a blocking usage budget is used up.
+var ErrBudgetExceeded = errors.New("usage budget exceeded")
+
+// UsageBudget represents the request limits of one endpoint, or all the endpoints. Zero means no limit.
+type UsageBudget struct {
+	Daily   int
+	Monthly int
+}
+
+// UsageWarning represents a budget about to be exceeded.
+type UsageWarning struct {
+	// Endpoint name, empty for the all endpoints budget.
+	Endpoint string
+	// "day" or "month".
+	Period string
+	Used   int
+	Limit  int
+}
+
+// UsageReport represents the usage of a date range.
+type UsageReport struct {
+	Start     time.Time
+	End       time.Time
+	Total     int
+	Endpoints map[string]int
+
+	// Used paid plan endpoints, sorted.
+	PaidEndpoints []string
+	// Used partial paid plan endpoints, sorted.
+	PartialPaidEndpoints []string
+}
+
+// UsageTracker counts the server calls per endpoint per day, UTC, and persists the counters to its file
+// SaveDelay after a call, so that the calls in between are written once. Call Save before exiting to write the last calls.
+// Responses served from the caches and coalesced requests are not counted.
+type UsageTracker struct {
+	// WarnAt is the fraction of a budget at which OnWarn is called, default 0.9.
+	WarnAt float64
+	// OnWarn, if set, is called once when a budget reaches WarnAt, and once when it is used up.
+	OnWarn func(UsageWarning)
+	// Block makes the calls fail with ErrBudgetExceeded once a budget is used up, instead of only warning.
+	Block bool
+	// SaveDelay is how long the counters wait after a call before being written to the file, default 5 seconds.
+	SaveDelay time.Duration
+	// KeepDays is the number of days of counters kept, default 400, older days are dropped when saving. Zero keeps them all.
+	KeepDays int
+	// OnSaveError, if set, is called with the error of a failed delayed write, as it cannot fail the calls.
+	OnSaveError func(error)
+
+	file    string
+	mu      sync.Mutex
+	days    map[string]map[string]int
+	budgets map[string]UsageBudget
+	pending bool
+	now     func() time.Time
+}
+
+// usageFile is the usage tracker file content.
+type usageFile struct {
+	Days map[string]map[string]int `json:"days"`
+}
+
+// NewUsageTracker creates a UsageTracker persisted to the file, loading its counters if the file exists.
+func NewUsageTracker(fileNameWithPath string) (*UsageTracker, error) {
+	if fileNameWithPath == "" {
+		return nil, errors.New("usage file path is required")
+	}
+	u := &UsageTracker{
+		WarnAt:    0.9,
+		SaveDelay: 5 * time.Second,
+		KeepDays:  400,
+		file:      fileNameWithPath,
+		days:      make(map[string]map[string]int),
+		budgets:   make(map[string]UsageBudget),
+		now:       time.Now,
+	}
+	data, err := ioutil.ReadFile(fileNameWithPath)
+	if os.IsNotExist(err) {
+		return u, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f usageFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	for day, counts := range f.Days {
+		u.days[day] = counts
+	}
+	return u, nil
+}
+
+// SetUsageTracker makes the Connecter count its server calls in the tracker, nil disables it.
+func (c *Connecter) SetUsageTracker(u *UsageTracker) {
+	c.usage = u
+}
+
+// SetBudget sets the budget of the endpoint, or of all the endpoints together if endpoint is empty.
+func (u *UsageTracker) SetBudget(endpoint string, b UsageBudget) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.budgets[endpoint] = b
+}
+
+// Used returns the number of calls to the endpoint, or all the endpoints if endpoint is empty,
+// on the day and in the month of t.
+func (u *UsageTracker) Used(endpoint string, t time.Time) (daily, monthly int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.used(endpoint, t)
+}
+
+// Report returns the usage of the days from start to end, both inclusive.
+func (u *UsageTracker) Report(start, end time.Time) UsageReport {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	r := UsageReport{Start: start, End: end, Endpoints: make(map[string]int)}
+	first, last := start.UTC().Format("2006-01-02"), end.UTC().Format("2006-01-02")
+	for day, counts := range u.days {
+		if day < first || day > last {
+			continue
+		}
+		for e, n := range counts {
+			r.Endpoints[e] += n
+			r.Total += n
+		}
+	}
+	for e := range r.Endpoints {
+		ep := PlanRequirements[e]
+		switch {
+		case ep.Paid:
+			r.PaidEndpoints = append(r.PaidEndpoints, e)
+		case ep.PartialPaid():
+			r.PartialPaidEndpoints = append(r.PartialPaidEndpoints, e)
+		}
+	}
+	sort.Strings(r.PaidEndpoints)
+	sort.Strings(r.PartialPaidEndpoints)
+	return r
+}
+
+// Save writes the counters to the file right away.
+func (u *UsageTracker) Save() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.save()
+}
+
+// Helper methods
+
+// record counts one call to the endpoint, after checking the budgets, and calls OnWarn with the reached budgets.
+func (u *UsageTracker) record(endpoint string) error {
+	warnings, err := u.count(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.OnWarn != nil {
+		for _, w := range warnings {
+			u.OnWarn(w)
+		}
+	}
+	return nil
+}
+
+// count counts one call to the endpoint, after checking the budgets, and returns the reached budgets.
+// The counters are written SaveDelay later, along with the next calls.
+func (u *UsageTracker) count(endpoint string) ([]UsageWarning, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	now := u.now()
+	var warnings []UsageWarning
+	for _, e := range []string{endpoint, ""} {
+		b, ok := u.budgets[e]
+		if !ok {
+			continue
+		}
+		daily, monthly := u.used(e, now)
+		for _, p := range []struct {
+			period string
+			used   int
+			limit  int
+		}{{"day", daily, b.Daily}, {"month", monthly, b.Monthly}} {
+			if p.limit <= 0 {
+				continue
+			}
+			if p.used >= p.limit && u.Block {
+				return nil, fmt.Errorf("%w : %v of %v requests per %v used", ErrBudgetExceeded, p.used, p.limit, p.period)
+			}
+			warnAt := int(math.Ceil(u.WarnAt * float64(p.limit)))
+			if p.used+1 == warnAt || p.used+1 == p.limit {
+				warnings = append(warnings, UsageWarning{Endpoint: e, Period: p.period, Used: p.used + 1, Limit: p.limit})
+			}
+		}
+	}
+
+	day := now.UTC().Format("2006-01-02")
+	if u.days[day] == nil {
+		u.days[day] = make(map[string]int)
+	}
+	u.days[day][endpoint]++
+	if !u.pending {
+		u.pending = true
+		time.AfterFunc(u.SaveDelay, u.delayedSave)
+	}
+	return warnings, nil
+}
+
+// delayedSave writes the counters to the file, and reports its error to OnSaveError.
+func (u *UsageTracker) delayedSave() {
+	u.mu.Lock()
+	err := u.save()
+	u.mu.Unlock()
+	if err != nil && u.OnSaveError != nil {
+		u.OnSaveError(err)
+	}
+}
+
+// used returns the daily and monthly calls to the endpoint, or all the endpoints if endpoint is empty.
+func (u *UsageTracker) used(endpoint string, t time.Time) (daily, monthly int) {
+	day := t.UTC().Format("2006-01-02")
+	month := day[:len("2006-01")]
+	for d, counts := range u.days {
+		if !strings.HasPrefix(d, month) {
+			continue
+		}
+		n := counts[endpoint]
+		if endpoint == "" {
+			n = 0
+			for _, v := range counts {
+				n += v
+			}
+		}
+		monthly += n
+		if d == day {
+			daily += n
+		}
+	}
+	return daily, monthly
+}
+
+// save drops the days older than KeepDays and writes the counters to the file.
+func (u *UsageTracker) save() error {
+	u.pending = false
+	if u.KeepDays > 0 {
+		oldest := u.now().UTC().AddDate(0, 0, 1-u.KeepDays).Format("2006-01-02")
+		for day := range u.days {
+			if day < oldest {
+				delete(u.days, day)
+			}
+		}
+	}
+	data, err := json.Marshal(usageFile{Days: u.days})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(u.file, data)
+}
@@ -0,0 +1,98 @@
+package gonomics
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestUsageTracker tests usage counting, persistence, budgets and the paid endpoints report.
+func TestUsageTracker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gonomics_usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "usage.json")
+
+	calls := 0
+	c := New(demoAPIKey)
+	c.HTTPClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return newTestResponse(req, http.StatusOK, `[]`), nil
+	})
+	u, err := NewUsageTracker(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetUsageTracker(u)
+	var warnings []UsageWarning
+	u.OnWarn = func(w UsageWarning) {
+		warnings = append(warnings, w)
+	}
+	u.Block = true
+	u.SetBudget(EndpointGlobalTicker, UsageBudget{Daily: 2})
+
+	for i := 0; i < 3; i++ {
+		_, err = c.GetGlobalTicker(GlobalTickerRequest{})
+	}
+	if !errors.Is(err, ErrBudgetExceeded) || calls != 2 {
+		t.Errorf("Something is wrong here, expected budget exceeded after 2 calls, got %v after %v calls.", err, calls)
+	}
+	if len(warnings) != 1 || warnings[0].Used != 2 || warnings[0].Period != "day" {
+		t.Errorf("Something is wrong here, unexpected warnings %v.", warnings)
+	}
+	if _, err := c.GetMarkets(MarketsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Counters are written once after SaveDelay, or by Save, and loaded back from the file.
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Errorf("Something is wrong here, expected no usage file before SaveDelay, got %v.", err)
+	}
+	u.mu.Lock()
+	u.days["2000-01-01"] = map[string]int{EndpointMarkets: 1}
+	u.mu.Unlock()
+	if err := u.Save(); err != nil {
+		t.Fatal(err)
+	}
+	u, err = NewUsageTracker(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if daily, monthly := u.Used("", now); daily != 3 || monthly != 3 {
+		t.Errorf("Something is wrong here, expected 3 calls, got %v daily and %v monthly.", daily, monthly)
+	}
+	if _, monthly := u.Used("", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)); monthly != 0 {
+		t.Errorf("Something is wrong here, expected days older than KeepDays dropped, got %v calls.", monthly)
+	}
+	r := u.Report(now, now)
+	if r.Total != 3 || r.Endpoints[EndpointGlobalTicker] != 2 || len(r.PaidEndpoints) != 1 || r.PaidEndpoints[0] != EndpointGlobalTicker {
+		t.Errorf("Something is wrong here, unexpected report %+v.", r)
+	}
+
+	// Delayed write errors are reported.
+	u, err = NewUsageTracker(filepath.Join(dir, "missing", "usage.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.SaveDelay = time.Millisecond
+	saveErrs := make(chan error, 1)
+	u.OnSaveError = func(err error) {
+		saveErrs <- err
+	}
+	c.SetUsageTracker(u)
+	if _, err := c.GetMarkets(MarketsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-saveErrs:
+	case <-time.After(5 * time.Second):
+		t.Error("Something is wrong here, expected save error.")
+	}
+}
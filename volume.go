@@ -48,7 +48,6 @@ func (c *Connecter) GetVolumeHistory(vhReq VolumeHistoryRequest) ([]VolumeHistor
 
 	// Formulate query params.
 	q := req.URL.Query()
-	q.Add("key", c.apiKey)
 	if !vhReq.Start.IsZero() {
 		q.Add("start", vhReq.Start.Format(time.RFC3339))
 	}
@@ -70,7 +69,7 @@ func (c *Connecter) GetVolumeHistory(vhReq VolumeHistoryRequest) ([]VolumeHistor
 	req.URL.RawQuery = q.Encode()
 
 	// Do the requset to server.
-	resp, err := c.do(req)
+	resp, err := c.do(req, vhReq)
 	if err != nil {
 		return nil, err
 	}